@@ -8,8 +8,13 @@ import (
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
-	// networkingv1 "k8s.io/api/networking/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/gruntwork-io/terratest/modules/helm"
 	"github.com/gruntwork-io/terratest/modules/k8s"
@@ -51,6 +56,62 @@ func generateNamespaceName() string {
 	return "readyset-" + strings.ToLower(random.UniqueId())
 }
 
+// renderTemplate renders a single template of the chart with the given options
+func renderTemplate(t *testing.T, options *helm.Options, templateFile string) (string, error) {
+	helmChartPath, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	helm.AddRepo(t, options, "readyset", "https://helm.releases.readyset.io")
+	helm.AddRepo(t, options, "hashicorp", "https://helm.releases.hashicorp.com")
+
+	return helm.RenderTemplateE(t, options, helmChartPath, "readyset", []string{templateFile})
+}
+
+// findDocument returns the document of the given kind from a template rendering several YAML documents
+func findDocument(t *testing.T, rendered string, kind string) string {
+	for _, document := range strings.Split(rendered, "\n---") {
+		if strings.Contains(document, "\nkind: "+kind+"\n") {
+			return document
+		}
+	}
+	require.FailNow(t, fmt.Sprintf("No %s found in rendered template", kind))
+	return ""
+}
+
+// findContainer looks up a container by name rather than by its position in the pod spec
+func findContainer(containers []corev1.Container, name string) (corev1.Container, bool) {
+	for _, c := range containers {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return corev1.Container{}, false
+}
+
+// requireContainer looks up a container of a pod spec by name, failing the test with the names of the containers
+// present when there is none
+func requireContainer(t *testing.T, pod corev1.PodSpec, name string) corev1.Container {
+	container, ok := findContainer(pod.Containers, name)
+	if !ok {
+		names := make([]string, 0, len(pod.Containers))
+		for _, c := range pod.Containers {
+			names = append(names, c.Name)
+		}
+		require.FailNow(t, fmt.Sprintf("No %s container found, available containers: %s", name, strings.Join(names, ", ")))
+	}
+	return container
+}
+
+// getEnv looks up an environment variable of a container by name
+func getEnv(container corev1.Container, name string) (corev1.EnvVar, bool) {
+	for _, e := range container.Env {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return corev1.EnvVar{}, false
+}
+
 func TestChart(t *testing.T) {
 	_, err := loadChartYaml(".")
 	require.NoError(t, err)
@@ -151,11 +212,9 @@ func TestServerStatefulSetDefault(t *testing.T) {
 
 	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &serverStatefulSet)
 
-	containers := serverStatefulSet.Spec.Template.Spec.Containers
-	containersExpected := 2
-	containersActual := len(containers)
-
-	assert.Equal(containersExpected, containersActual, fmt.Sprintf("Expected number of containers: %d, actual: %d", containersExpected, containersActual))
+	podSpec := serverStatefulSet.Spec.Template.Spec
+	requireContainer(t, podSpec, "consul-agent")
+	serverContainer := requireContainer(t, podSpec, "readyset-server")
 
 	assert.Equal(deploymentName, serverStatefulSet.Name, "Deployments should be equal")
 	assert.Equal(namespace, serverStatefulSet.ObjectMeta.Namespace, fmt.Sprintf("Namespaces should be equal: %v\n", serverStatefulSet.ObjectMeta))
@@ -164,10 +223,10 @@ func TestServerStatefulSetDefault(t *testing.T) {
 
 	// The default values should yield an environment with 15 elemnents for readyset-server
 	arrayLen := 15
-	assert.Equal(arrayLen, len(containers[1].Env), fmt.Sprintf("Length of environment variable array should be %d", arrayLen))
+	assert.Equal(arrayLen, len(serverContainer.Env), fmt.Sprintf("Length of environment variable array should be %d", arrayLen))
 
 	// Ensure none of the env vars enable replication tables
-	for _, v := range containers[1].Env {
+	for _, v := range serverContainer.Env {
 		assert.NotEqual("REPLICATION_TABLES", v.Name)
 	}
 }
@@ -222,3 +281,5881 @@ func TestAdapterRoles(t *testing.T) {
 	require.NoError(t, err)
 	helm.UnmarshalK8SYaml(t, renderedRbacTemplate, &adapterRole)
 }
+
+func TestExternalAuthorityRequiresConsulDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.authority.external.address"] = "consul.example.com:8500"
+
+	options := defaultOptions(namespace, chartValues)
+
+	// Helm cannot gate the consul dependency on the external address, so the render fails fast instead of deploying
+	// the bundled cluster next to the external one
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail with an external authority while the bundled Consul is enabled")
+
+	chartValues["consul.enabled"] = "false"
+
+	options = defaultOptions(namespace, chartValues)
+
+	// The bundled consul chart is gated on consul.enabled, so none of its templates should render
+	_, err = renderTemplate(t, options, "charts/consul/templates/server-statefulset.yaml")
+	assert.Error(err, "Consul server StatefulSet should not be rendered when using an external authority")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	authorityAddress, ok := getEnv(serverContainer, "AUTHORITY_ADDRESS")
+	require.True(t, ok, "AUTHORITY_ADDRESS should be set on readyset-server")
+	assert.Equal(options.SetValues["readyset.authority.external.address"], authorityAddress.Value, "AUTHORITY_ADDRESS should point at the external authority")
+}
+
+func TestExternalAuthorityToken(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["consul.enabled"] = "false"
+	chartValues["readyset.authority.external.address"] = "consul.example.com:8500"
+	chartValues["readyset.authority.external.token.secretName"] = "readyset-consul-token"
+	chartValues["readyset.authority.external.token.secretKey"] = "acl-token"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	token, ok := getEnv(serverContainer, "CONSUL_HTTP_TOKEN")
+	require.True(t, ok, "CONSUL_HTTP_TOKEN should be set on readyset-server")
+	require.NotNil(t, token.ValueFrom, "CONSUL_HTTP_TOKEN should be sourced from a secret")
+	require.NotNil(t, token.ValueFrom.SecretKeyRef, "CONSUL_HTTP_TOKEN should be sourced from a secret")
+	assert.Equal(options.SetValues["readyset.authority.external.token.secretName"], token.ValueFrom.SecretKeyRef.Name, "Token secret name should be equal")
+	assert.Equal(options.SetValues["readyset.authority.external.token.secretKey"], token.ValueFrom.SecretKeyRef.Key, "Token secret key should be equal")
+}
+
+func TestAdapterReplicaCount(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.replicaCount"] = "3"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	require.NotNil(t, adapterDeployment.Spec.Replicas, "Replicas should be set")
+	assert.Equal(int32(3), *adapterDeployment.Spec.Replicas, "Replicas should equal 3")
+}
+
+func TestAdapterReplicaCountRejectsZero(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.replicaCount"] = "0"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail with zero adapter replicas")
+}
+
+func TestAdapterPprofEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.pprof.enabled"] = "true"
+	chartValues["readyset.adapter.pprof.port"] = "6061"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	pprofAddress, ok := getEnv(adapterContainer, "PPROF_ADDRESS")
+	require.True(t, ok, "PPROF_ADDRESS should be set when pprof is enabled")
+	assert.Equal("0.0.0.0:6061", pprofAddress.Value, "PPROF_ADDRESS should listen on the configured port")
+
+	var pprofPort *corev1.ContainerPort
+	for i, p := range adapterContainer.Ports {
+		if p.Name == "pprof" {
+			pprofPort = &adapterContainer.Ports[i]
+		}
+	}
+	require.NotNil(t, pprofPort, "readyset-adapter should expose a port named pprof")
+	assert.Equal(int32(6061), pprofPort.ContainerPort, "pprof port should equal the configured port")
+}
+
+func TestAdapterPprofDisabledByDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	_, ok = getEnv(adapterContainer, "PPROF_ADDRESS")
+	assert.False(ok, "PPROF_ADDRESS should not be set by default")
+
+	for _, p := range adapterContainer.Ports {
+		assert.NotEqual("pprof", p.Name, "readyset-adapter should not expose a pprof port by default")
+	}
+}
+
+func TestServerSidecarResources(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.sidecar.resources.requests.cpu"] = "100m"
+	chartValues["readyset.server.sidecar.resources.requests.memory"] = "128Mi"
+	chartValues["readyset.server.sidecar.resources.limits.memory"] = "256Mi"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	sidecarContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "consul-agent")
+	require.True(t, ok, "consul-agent sidecar container should exist")
+
+	cpuRequest := sidecarContainer.Resources.Requests[corev1.ResourceCPU]
+	memoryRequest := sidecarContainer.Resources.Requests[corev1.ResourceMemory]
+	memoryLimit := sidecarContainer.Resources.Limits[corev1.ResourceMemory]
+
+	assert.Equal(options.SetValues["readyset.server.sidecar.resources.requests.cpu"], cpuRequest.String(), "Sidecar CPU request should be equal")
+	assert.Equal(options.SetValues["readyset.server.sidecar.resources.requests.memory"], memoryRequest.String(), "Sidecar memory request should be equal")
+	assert.Equal(options.SetValues["readyset.server.sidecar.resources.limits.memory"], memoryLimit.String(), "Sidecar memory limit should be equal")
+}
+
+func TestReadWriteSplitService(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.readWriteSplit.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var readerService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-readonly-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &readerService)
+
+	assert.Equal("readyset-reader", readerService.Name, "Services should be equal")
+	assert.Equal("readyset-adapter", readerService.Spec.Selector["app.kubernetes.io/name"], "Reader service should select adapter pods")
+	require.Len(t, readerService.Spec.Ports, 1)
+	assert.Equal("sql", readerService.Spec.Ports[0].Name, "Reader service should expose the SQL port")
+	assert.Equal(int32(5432), readerService.Spec.Ports[0].Port, "Reader service port should equal the adapter SQL port")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	passthrough, ok := getEnv(adapterContainer, "PASSTHROUGH_WRITES")
+	require.True(t, ok, "PASSTHROUGH_WRITES should be set when the read/write split is enabled")
+	assert.Equal("true", passthrough.Value, "PASSTHROUGH_WRITES should be enabled")
+}
+
+func TestUpstreamPoolSettings(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.upstream.poolSize"] = "16"
+	chartValues["readyset.upstream.connectTimeoutSeconds"] = "10"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	for _, container := range []corev1.Container{adapterContainer, serverContainer} {
+		poolSize, ok := getEnv(container, "UPSTREAM_DB_POOL_SIZE")
+		require.True(t, ok, fmt.Sprintf("UPSTREAM_DB_POOL_SIZE should be set on %s", container.Name))
+		assert.Equal(options.SetValues["readyset.upstream.poolSize"], poolSize.Value, "Pool size should be equal")
+
+		connectTimeout, ok := getEnv(container, "UPSTREAM_DB_CONNECT_TIMEOUT")
+		require.True(t, ok, fmt.Sprintf("UPSTREAM_DB_CONNECT_TIMEOUT should be set on %s", container.Name))
+		assert.Equal(options.SetValues["readyset.upstream.connectTimeoutSeconds"], connectTimeout.Value, "Connect timeout should be equal")
+
+		_, ok = getEnv(container, "UPSTREAM_DB_IDLE_TIMEOUT")
+		assert.False(ok, fmt.Sprintf("UPSTREAM_DB_IDLE_TIMEOUT should not be set on %s when left unset", container.Name))
+	}
+}
+
+func TestUpstreamPoolSettingsDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	// Unset options should be left to ReadySet's own defaults
+	for _, name := range []string{"UPSTREAM_DB_POOL_SIZE", "UPSTREAM_DB_CONNECT_TIMEOUT", "UPSTREAM_DB_IDLE_TIMEOUT"} {
+		_, ok := getEnv(serverContainer, name)
+		assert.False(ok, fmt.Sprintf("%s should not be set by default", name))
+	}
+}
+
+func TestMetricsServiceDiscoveryLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.metrics.enabled"] = "true"
+	chartValues["readyset.metrics.service.discoveryLabels.monitoring"] = "readyset"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var metricsService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-metrics-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &metricsService)
+
+	assert.Equal("readyset-metrics", metricsService.Name, "Services should be equal")
+	assert.Equal(options.SetValues["readyset.metrics.service.discoveryLabels.monitoring"], metricsService.ObjectMeta.Labels["monitoring"], "Discovery label should be present")
+	assert.Equal(options.SetValues["readyset.deployment"], metricsService.ObjectMeta.Labels["app.kubernetes.io/instance"], "app.kubernetes.io/instance should be equal")
+}
+
+func TestAdapterOnServerUnavailableError(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.onServerUnavailable"] = "error"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	onServerUnavailable, ok := getEnv(adapterContainer, "ON_SERVER_UNAVAILABLE")
+	require.True(t, ok, "ON_SERVER_UNAVAILABLE should be set")
+	assert.Equal(options.SetValues["readyset.adapter.onServerUnavailable"], onServerUnavailable.Value, "ON_SERVER_UNAVAILABLE should equal 'error'")
+}
+
+func TestAdapterOnServerUnavailableRejectsInvalid(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.onServerUnavailable"] = "retry"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for an unknown onServerUnavailable value")
+}
+
+func TestPodAnnotationsMergePrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["commonAnnotations.example\\.com/owner"] = "common"
+	chartValues["readyset.server.podAnnotations.example\\.com/owner"] = "server"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	// Component annotations take precedence over the common ones
+	assert.Equal("server", serverStatefulSet.Spec.Template.ObjectMeta.Annotations["example.com/owner"], "Server pod annotation should resolve to the server value")
+	assert.Equal("common", adapterDeployment.Spec.Template.ObjectMeta.Annotations["example.com/owner"], "Adapter pod annotation should resolve to the common value")
+}
+
+func TestAdapterCustomPorts(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.sqlPort"] = "15432"
+	chartValues["readyset.adapter.httpPort"] = "16034"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	containerPorts := []int32{}
+	for _, p := range adapterContainer.Ports {
+		containerPorts = append(containerPorts, p.ContainerPort)
+	}
+	assert.Contains(containerPorts, int32(15432), "readyset-adapter should expose the configured SQL port")
+	assert.Contains(containerPorts, int32(16034), "readyset-adapter should expose the configured HTTP port")
+
+	listenAddress, ok := getEnv(adapterContainer, "LISTEN_ADDRESS")
+	require.True(t, ok, "LISTEN_ADDRESS should be set")
+	assert.Equal("0.0.0.0:15432", listenAddress.Value, "LISTEN_ADDRESS should use the configured SQL port")
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	targetPorts := map[int32]int32{}
+	for _, p := range adapterService.Spec.Ports {
+		targetPorts[p.Port] = p.TargetPort.IntVal
+	}
+	assert.Equal(int32(15432), targetPorts[5432], "Service SQL port should target the configured SQL port")
+	assert.Equal(int32(16034), targetPorts[6034], "Service HTTP port should target the configured HTTP port")
+}
+
+func TestAdapterDefaultSqlPortByType(t *testing.T) {
+	assert := assert.New(t)
+
+	listenAddresses := map[string]string{}
+	for _, adapterType := range []string{"postgresql", "mysql"} {
+		namespace := generateNamespaceName()
+		chartValues := cliValues()
+
+		// Set values as though they are passed via the CLI
+		chartValues["readyset.adapter.type"] = adapterType
+
+		options := defaultOptions(namespace, chartValues)
+
+		var adapterDeployment appsv1.Deployment
+
+		renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+		require.NoError(t, err)
+
+		helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+		adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+		require.True(t, ok, "readyset-adapter container should exist")
+
+		listenAddress, ok := getEnv(adapterContainer, "LISTEN_ADDRESS")
+		require.True(t, ok, "LISTEN_ADDRESS should be set")
+		listenAddresses[adapterType] = listenAddress.Value
+	}
+
+	assert.Equal("0.0.0.0:5433", listenAddresses["postgresql"], "PostgreSQL adapters should default to port 5433")
+	assert.Equal("0.0.0.0:3307", listenAddresses["mysql"], "MySQL adapters should default to port 3307")
+	assert.NotEqual(listenAddresses["postgresql"], listenAddresses["mysql"], "Default SQL ports should differ between adapter types")
+}
+
+func TestServerReplicationMaxBufferBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.replication.maxBufferBytes"] = "256Mi"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	maxBuffer, ok := getEnv(serverContainer, "REPLICATION_MAX_BUFFER_SIZE")
+	require.True(t, ok, "REPLICATION_MAX_BUFFER_SIZE should be set")
+	assert.Equal(options.SetValues["readyset.server.replication.maxBufferBytes"], maxBuffer.Value, "REPLICATION_MAX_BUFFER_SIZE should equal '256Mi'")
+}
+
+func TestAdapterAutoscalingBehavior(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.autoscaling.enabled"] = "true"
+	chartValues["readyset.adapter.autoscaling.behavior.scaleDown.stabilizationWindowSeconds"] = "300"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterHpa autoscalingv2.HorizontalPodAutoscaler
+
+	renderedHpaTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-hpa.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedHpaTemplate, &adapterHpa)
+
+	assert.Equal("readyset-adapter", adapterHpa.Spec.ScaleTargetRef.Name, "HPA should target the adapter Deployment")
+	require.NotNil(t, adapterHpa.Spec.Behavior, "HPA behavior should be set")
+	require.NotNil(t, adapterHpa.Spec.Behavior.ScaleDown, "HPA scale down behavior should be set")
+	require.NotNil(t, adapterHpa.Spec.Behavior.ScaleDown.StabilizationWindowSeconds, "HPA scale down stabilization window should be set")
+	assert.Equal(int32(300), *adapterHpa.Spec.Behavior.ScaleDown.StabilizationWindowSeconds, "Scale down stabilization window should equal 300s")
+}
+
+func TestServerWaitForUpstreamInitContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.type"] = "postgresql"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	initContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.InitContainers, "wait-for-upstream")
+	require.True(t, ok, "wait-for-upstream init container should exist by default")
+	assert.Contains(strings.Join(initContainer.Command, " "), "pg_isready", "PostgreSQL upstreams should be checked with pg_isready")
+}
+
+func TestServerWaitForUpstreamDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.waitForUpstream.enabled"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	_, ok := findContainer(serverStatefulSet.Spec.Template.Spec.InitContainers, "wait-for-upstream")
+	assert.False(ok, "wait-for-upstream init container should not exist when disabled")
+}
+
+func TestFullnameOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["fullnameOverride"] = "readyset-staging"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	assert.Equal("readyset-staging-adapter", adapterDeployment.Name, "Deployment name should use the fullname override")
+	assert.Equal("readyset-staging-adapter", adapterService.Name, "Service name should use the fullname override")
+}
+
+func TestNameOverrideLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["nameOverride"] = "cache"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal("cache-adapter", adapterDeployment.ObjectMeta.Labels["app.kubernetes.io/name"], "app.kubernetes.io/name should reflect the name override")
+	assert.Equal("cache-adapter", adapterDeployment.Spec.Selector.MatchLabels["app.kubernetes.io/name"], "Selector should reflect the name override")
+	assert.Equal("cache-adapter", adapterDeployment.Spec.Template.ObjectMeta.Labels["app.kubernetes.io/name"], "Pod labels should reflect the name override")
+}
+
+func TestAdapterAutoscalingCustomMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-hpa-custom-metrics.yaml")
+
+	var adapterHpa autoscalingv2.HorizontalPodAutoscaler
+
+	renderedHpaTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-hpa.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedHpaTemplate, &adapterHpa)
+
+	var cpuMetric, qpsMetric *autoscalingv2.MetricSpec
+	for i, m := range adapterHpa.Spec.Metrics {
+		switch {
+		case m.Type == autoscalingv2.ResourceMetricSourceType && m.Resource.Name == corev1.ResourceCPU:
+			cpuMetric = &adapterHpa.Spec.Metrics[i]
+		case m.Type == autoscalingv2.PodsMetricSourceType && m.Pods.Metric.Name == "readyset_queries_per_second":
+			qpsMetric = &adapterHpa.Spec.Metrics[i]
+		}
+	}
+
+	require.NotNil(t, cpuMetric, "HPA should keep the CPU utilization target")
+	require.NotNil(t, qpsMetric, "HPA should include the custom queries per second metric")
+	assert.Equal("500", qpsMetric.Pods.Target.AverageValue.String(), "Custom metric target should be equal")
+}
+
+func TestLogLevelAndFormatEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.logLevel"] = "debug"
+	chartValues["readyset.logFormat"] = "json"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	for _, container := range []corev1.Container{adapterContainer, serverContainer} {
+		logLevel, ok := getEnv(container, "LOG_LEVEL")
+		require.True(t, ok, fmt.Sprintf("LOG_LEVEL should be set on %s", container.Name))
+		assert.Equal(options.SetValues["readyset.logLevel"], logLevel.Value, "Log level should be equal")
+
+		logFormat, ok := getEnv(container, "LOG_FORMAT")
+		require.True(t, ok, fmt.Sprintf("LOG_FORMAT should be set on %s", container.Name))
+		assert.Equal(options.SetValues["readyset.logFormat"], logFormat.Value, "Log format should be equal")
+	}
+}
+
+func TestLogLevelRejectsInvalid(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.logLevel"] = "verbose"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail for an unknown log level")
+}
+
+func TestServerBackupCronJob(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.backup.enabled"] = "true"
+	chartValues["readyset.server.backup.schedule"] = "0 */6 * * *"
+	chartValues["readyset.server.backup.destination"] = "s3://readyset-backups/helm-test"
+	chartValues["readyset.server.backup.image"] = "example.com/readyset-backup:latest"
+	chartValues["readyset.server.backup.credentialsSecret.name"] = "readyset-backup-credentials"
+	chartValues["readyset.server.backup.credentialsSecret.key"] = "aws-credentials"
+	chartValues["readyset.server.backup.successfulJobsHistoryLimit"] = "5"
+	chartValues["readyset.server.backup.failedJobsHistoryLimit"] = "2"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var backupCronJob batchv1.CronJob
+
+	renderedCronJobTemplate, err := renderTemplate(t, options, "templates/readyset-server-backup-cronjob.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedCronJobTemplate, &backupCronJob)
+
+	assert.Equal(options.SetValues["readyset.server.backup.schedule"], backupCronJob.Spec.Schedule, "Schedules should be equal")
+	require.NotNil(t, backupCronJob.Spec.SuccessfulJobsHistoryLimit, "successfulJobsHistoryLimit should be set")
+	assert.Equal(int32(5), *backupCronJob.Spec.SuccessfulJobsHistoryLimit, "successfulJobsHistoryLimit should be equal")
+	require.NotNil(t, backupCronJob.Spec.FailedJobsHistoryLimit, "failedJobsHistoryLimit should be set")
+	assert.Equal(int32(2), *backupCronJob.Spec.FailedJobsHistoryLimit, "failedJobsHistoryLimit should be equal")
+
+	backupContainer, ok := findContainer(backupCronJob.Spec.JobTemplate.Spec.Template.Spec.Containers, "backup")
+	require.True(t, ok, "backup container should exist")
+
+	deployment, ok := getEnv(backupContainer, "DEPLOYMENT")
+	require.True(t, ok, "DEPLOYMENT should be set")
+	assert.Equal(options.SetValues["readyset.deployment"], deployment.Value, "Deployments should be equal")
+
+	upstreamURL, ok := getEnv(backupContainer, "UPSTREAM_DB_URL")
+	require.True(t, ok, "UPSTREAM_DB_URL should be set")
+	require.NotNil(t, upstreamURL.ValueFrom, "UPSTREAM_DB_URL should be sourced from a secret")
+	require.NotNil(t, upstreamURL.ValueFrom.SecretKeyRef, "UPSTREAM_DB_URL should be sourced from a secret")
+	assert.Equal("readyset-upstream-database", upstreamURL.ValueFrom.SecretKeyRef.Name, "Upstream secret name should be equal")
+
+	destination, ok := getEnv(backupContainer, "BACKUP_DESTINATION")
+	require.True(t, ok, "BACKUP_DESTINATION should be set")
+	assert.Equal(options.SetValues["readyset.server.backup.destination"], destination.Value, "Destinations should be equal")
+
+	credentials, ok := getEnv(backupContainer, "BACKUP_CREDENTIALS")
+	require.True(t, ok, "BACKUP_CREDENTIALS should be set")
+	require.NotNil(t, credentials.ValueFrom, "BACKUP_CREDENTIALS should be sourced from a secret")
+	require.NotNil(t, credentials.ValueFrom.SecretKeyRef, "BACKUP_CREDENTIALS should be sourced from a secret")
+	assert.Equal(options.SetValues["readyset.server.backup.credentialsSecret.name"], credentials.ValueFrom.SecretKeyRef.Name, "Credentials secret name should be equal")
+	assert.Equal(options.SetValues["readyset.server.backup.credentialsSecret.key"], credentials.ValueFrom.SecretKeyRef.Key, "Credentials secret key should be equal")
+
+	// The template renders nothing unless readyset.server.backup.enabled is true
+	_, err = renderTemplate(t, defaultOptions(generateNamespaceName(), cliValues()), "templates/readyset-server-backup-cronjob.yaml")
+	require.Error(t, err, "Backup CronJob should not be rendered by default")
+}
+
+func TestAdapterServiceAppProtocol(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.service.appProtocol"] = "postgresql"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	var sqlPort *corev1.ServicePort
+	for i, p := range adapterService.Spec.Ports {
+		if p.Port == 5432 {
+			sqlPort = &adapterService.Spec.Ports[i]
+		}
+	}
+	require.NotNil(t, sqlPort, "Adapter Service should expose the SQL port")
+	require.NotNil(t, sqlPort.AppProtocol, "SQL port should advertise an appProtocol")
+	assert.Equal(options.SetValues["readyset.adapter.service.appProtocol"], *sqlPort.AppProtocol, "appProtocol should equal 'postgresql'")
+}
+
+func TestUpstreamTLSMountAndMode(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.upstream.tls.enabled"] = "true"
+	chartValues["readyset.upstream.tls.caSecret"] = "upstream-ca"
+	chartValues["readyset.upstream.tls.mode"] = "verify-full"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var caVolume *corev1.Volume
+	for i, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == options.SetValues["readyset.upstream.tls.caSecret"] {
+			caVolume = &serverStatefulSet.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, caVolume, "CA secret should be mounted as a volume")
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	var caMount *corev1.VolumeMount
+	for i, m := range serverContainer.VolumeMounts {
+		if m.Name == caVolume.Name {
+			caMount = &serverContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, caMount, "CA volume should be mounted into readyset-server")
+	assert.Equal("/etc/readyset/upstream-ca", caMount.MountPath, "CA mount path should be equal")
+
+	upstreamUrl, ok := getEnv(serverContainer, "UPSTREAM_DB_URL")
+	require.True(t, ok, "UPSTREAM_DB_URL should be set")
+	assert.Contains(upstreamUrl.Value, "sslmode=verify-full", "Upstream URL should carry the verify mode")
+	assert.Contains(upstreamUrl.Value, "sslrootcert=/etc/readyset/upstream-ca/ca.crt", "Upstream URL should reference the mounted CA")
+}
+
+func TestUpstreamTLSDisabledByDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	for _, m := range serverContainer.VolumeMounts {
+		assert.NotEqual("/etc/readyset/upstream-ca", m.MountPath, "CA should not be mounted by default")
+	}
+
+	upstreamUrl, _ := getEnv(serverContainer, "UPSTREAM_DB_URL")
+	assert.NotContains(upstreamUrl.Value, "sslrootcert", "Upstream URL should not reference a CA by default")
+}
+
+func TestPvcAnnotatorJob(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.persistence.annotatePvcsJob.enabled"] = "true"
+	chartValues["readyset.server.persistence.annotatePvcsJob.annotations.backup-policy"] = "daily"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var annotatorJob batchv1.Job
+
+	renderedJobTemplate, err := renderTemplate(t, options, "templates/readyset-pvc-annotator-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedJobTemplate, "Job"), &annotatorJob)
+
+	assert.Contains(annotatorJob.ObjectMeta.Annotations["helm.sh/hook"], "post-upgrade", "Job should run as a post-upgrade hook")
+
+	annotatorContainer, ok := findContainer(annotatorJob.Spec.Template.Spec.Containers, "pvc-annotator")
+	require.True(t, ok, "pvc-annotator container should exist")
+
+	command := strings.Join(annotatorContainer.Command, " ")
+	assert.Contains(command, "app.kubernetes.io/name=readyset-server", "Job should select the readyset-server PVCs")
+	assert.Contains(command, fmt.Sprintf("app.kubernetes.io/instance=%s", options.SetValues["readyset.deployment"]), "Job should select the PVCs of this deployment")
+	assert.Contains(command, "backup-policy=daily", "Job should apply the configured annotations")
+}
+
+func TestServerReaders(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.readers"] = "4"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	readers, ok := getEnv(serverContainer, "READER_REPLICAS")
+	require.True(t, ok, "READER_REPLICAS should be set")
+	assert.Equal(options.SetValues["readyset.server.readers"], readers.Value, "READER_REPLICAS should equal 4")
+}
+
+func TestServerReplicationTablesIgnore(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.replication_tables"] = "public.*"
+	chartValues["readyset.server.replication_tables_ignore"] = "public.audit_log"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	ignore, ok := getEnv(serverContainer, "REPLICATION_TABLES_IGNORE")
+	require.True(t, ok, "REPLICATION_TABLES_IGNORE should be set")
+	assert.Equal(options.SetValues["readyset.server.replication_tables_ignore"], ignore.Value, "REPLICATION_TABLES_IGNORE should be 'public.audit_log'")
+}
+
+func TestServerReplicationTablesAndIgnoreCoexist(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	// Commas are escaped so that --set does not split the lists into separate keys
+	chartValues["readyset.server.replication_tables"] = "public.*\\,myschema.mytable"
+	chartValues["readyset.server.replication_tables_ignore"] = "public.audit_log\\,public.events"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	tables, ok := getEnv(serverContainer, "REPLICATION_TABLES")
+	require.True(t, ok, "REPLICATION_TABLES should be set")
+	assert.Equal("public.*,myschema.mytable", tables.Value, "REPLICATION_TABLES should be equal")
+
+	ignore, ok := getEnv(serverContainer, "REPLICATION_TABLES_IGNORE")
+	require.True(t, ok, "REPLICATION_TABLES_IGNORE should be set")
+	assert.Equal("public.audit_log,public.events", ignore.Value, "REPLICATION_TABLES_IGNORE should be equal")
+}
+
+func TestServerReplicationTablesIgnoreRequiresScope(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.replication_tables_ignore"] = "public.audit_log"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail when ignoring tables without a replication scope")
+}
+
+func TestPreUpgradeHookJob(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.hooks.preUpgradeCheck.enabled"] = "true"
+	chartValues["readyset.server.imageTag"] = "stable-2023-08-01"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var preUpgradeJob batchv1.Job
+
+	renderedJobTemplate, err := renderTemplate(t, options, "templates/readyset-preupgrade-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedJobTemplate, &preUpgradeJob)
+
+	assert.Equal("pre-upgrade", preUpgradeJob.ObjectMeta.Annotations["helm.sh/hook"], "Job should run as a pre-upgrade hook")
+	assert.Equal("before-hook-creation,hook-succeeded", preUpgradeJob.ObjectMeta.Annotations["helm.sh/hook-delete-policy"], "Hook delete policies should be equal")
+
+	checkContainer, ok := findContainer(preUpgradeJob.Spec.Template.Spec.Containers, "preupgrade-check")
+	require.True(t, ok, "preupgrade-check container should exist")
+	assert.True(strings.HasSuffix(checkContainer.Image, ":"+options.SetValues["readyset.server.imageTag"]), "Job should reuse the readyset-server image")
+
+	authorityAddress, ok := getEnv(checkContainer, "AUTHORITY_ADDRESS")
+	require.True(t, ok, "AUTHORITY_ADDRESS should be set")
+	assert.NotEmpty(authorityAddress.Value, "AUTHORITY_ADDRESS should not be empty")
+}
+
+func TestPreUpgradeHookJobDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-preupgrade-job.yaml")
+	require.Error(t, err, "Pre-upgrade Job should not be rendered by default")
+}
+
+func TestPriorityClassCreated(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.priorityClass.create"] = "true"
+	chartValues["readyset.priorityClass.name"] = "readyset-critical"
+	chartValues["readyset.priorityClass.value"] = "100000"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var priorityClass schedulingv1.PriorityClass
+
+	renderedPriorityClassTemplate, err := renderTemplate(t, options, "templates/readyset-priorityclass.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedPriorityClassTemplate, &priorityClass)
+
+	assert.Equal(options.SetValues["readyset.priorityClass.name"], priorityClass.Name, "PriorityClass names should be equal")
+	assert.Equal(int32(100000), priorityClass.Value, "PriorityClass values should be equal")
+	assert.False(priorityClass.GlobalDefault, "PriorityClass should not be a global default")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal(priorityClass.Name, serverStatefulSet.Spec.Template.Spec.PriorityClassName, "Server pods should reference the PriorityClass")
+	assert.Equal(priorityClass.Name, adapterDeployment.Spec.Template.Spec.PriorityClassName, "Adapter pods should reference the PriorityClass")
+}
+
+func TestServerUpdateStrategyPartition(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.updateStrategy.type"] = "RollingUpdate"
+	chartValues["readyset.server.updateStrategy.rollingUpdate.partition"] = "2"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(appsv1.RollingUpdateStatefulSetStrategyType, serverStatefulSet.Spec.UpdateStrategy.Type, "Update strategy should be RollingUpdate")
+	require.NotNil(t, serverStatefulSet.Spec.UpdateStrategy.RollingUpdate, "Rolling update settings should be set")
+	require.NotNil(t, serverStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition, "Partition should be set")
+	assert.Equal(int32(2), *serverStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition, "Partition should equal 2")
+}
+
+func TestServerUpdateStrategyDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(appsv1.RollingUpdateStatefulSetStrategyType, serverStatefulSet.Spec.UpdateStrategy.Type, "Update strategy should default to RollingUpdate")
+	if serverStatefulSet.Spec.UpdateStrategy.RollingUpdate != nil {
+		assert.Nil(serverStatefulSet.Spec.UpdateStrategy.RollingUpdate.Partition, "Partition should not be set by default")
+	}
+}
+
+func TestAdapterCacheQueryTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.cacheQueryTypes"] = "{SELECT,SHOW}"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	cacheQueryTypes, ok := getEnv(adapterContainer, "CACHE_QUERY_TYPES")
+	require.True(t, ok, "CACHE_QUERY_TYPES should be set")
+	assert.Equal("SELECT,SHOW", cacheQueryTypes.Value, "CACHE_QUERY_TYPES should equal 'SELECT,SHOW'")
+}
+
+func TestServerSnapshotStartupProbe(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.snapshot.disableLivenessDuring"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	startupProbe := serverContainer.StartupProbe
+	require.NotNil(t, startupProbe, "readyset-server should have a startup probe")
+	assert.Equal(int32(30), startupProbe.PeriodSeconds, "Startup probe period should be equal")
+	assert.Equal(int32(960), startupProbe.FailureThreshold, "Startup probe failure threshold should be equal")
+
+	// Kubernetes holds the liveness probe back until the startup probe succeeds
+	assert.NotNil(serverContainer.LivenessProbe, "readyset-server should keep its liveness probe")
+}
+
+func TestAdapterStrategyMaxSurge(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.strategy.rollingUpdate.maxSurge"] = "2"
+	chartValues["readyset.adapter.strategy.rollingUpdate.maxUnavailable"] = "1"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	strategy := adapterDeployment.Spec.Strategy
+	assert.Equal(appsv1.RollingUpdateDeploymentStrategyType, strategy.Type, "Strategy type should be RollingUpdate")
+	require.NotNil(t, strategy.RollingUpdate, "Rolling update settings should be set")
+	assert.Equal("2", strategy.RollingUpdate.MaxSurge.String(), "maxSurge should be equal")
+	assert.Equal("1", strategy.RollingUpdate.MaxUnavailable.String(), "maxUnavailable should be equal")
+}
+
+func TestAdapterStrategyDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	strategy := adapterDeployment.Spec.Strategy
+	assert.Equal(appsv1.RollingUpdateDeploymentStrategyType, strategy.Type, "Strategy type should default to RollingUpdate")
+	require.NotNil(t, strategy.RollingUpdate, "Rolling update settings should be set")
+	assert.Equal(0, strategy.RollingUpdate.MaxUnavailable.IntValue(), "maxUnavailable should default to 0")
+}
+
+func TestServerExtraArgsTemplated(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/server-templated-extra-args.yaml")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	assert.Contains(serverContainer.Args, "--cluster-name=readyset", "Args should contain the interpolated release name")
+	assert.Contains(serverContainer.Args, "--namespace="+namespace, "Args should contain the interpolated release namespace")
+}
+
+func TestCommonLabelsOnAllResources(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.readWriteSplit.enabled"] = "true"
+	chartValues["readyset.metrics.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/common-labels.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal("1234", adapterDeployment.Labels["cost-center"], "Deployment cost-center label should be equal")
+	assert.Equal("readyset-adapter", adapterDeployment.Labels["app.kubernetes.io/name"], "commonLabels should not override the chart's own labels")
+	assert.NotContains(adapterDeployment.Spec.Selector.MatchLabels, "cost-center", "Deployment selector should not contain commonLabels")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal("1234", serverStatefulSet.Labels["cost-center"], "StatefulSet cost-center label should be equal")
+	assert.Equal(map[string]string{
+		"app.kubernetes.io/name":     "readyset-server",
+		"app.kubernetes.io/instance": "helm-test-readyset",
+	}, serverStatefulSet.Spec.Selector.MatchLabels, "StatefulSet selector labels should be unchanged")
+
+	var adapterRole rbacv1.Role
+
+	renderedRbacTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-role.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedRbacTemplate, "Role"), &adapterRole)
+
+	assert.Equal("1234", adapterRole.Labels["cost-center"], "Role cost-center label should be equal")
+
+	for _, templateFile := range []string{
+		"templates/readyset-adapter-service.yaml",
+		"templates/readyset-readonly-service.yaml",
+		"templates/readyset-metrics-service.yaml",
+	} {
+		var service corev1.Service
+
+		renderedServiceTemplate, err := renderTemplate(t, options, templateFile)
+		require.NoError(t, err)
+
+		helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &service)
+
+		assert.Equal("1234", service.Labels["cost-center"], templateFile+" cost-center label should be equal")
+		assert.Equal("data-platform", service.Labels["team"], templateFile+" team label should be equal")
+		assert.NotContains(service.Spec.Selector, "cost-center", templateFile+" selector should not contain commonLabels")
+	}
+}
+
+func TestAdapterClusterRole(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.rbac.clusterScope"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterClusterRole rbacv1.ClusterRole
+
+	renderedRbacTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-clusterrole.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedRbacTemplate, "ClusterRole"), &adapterClusterRole)
+
+	require.Len(t, adapterClusterRole.Rules, 1, "ClusterRole should have a single rule")
+	assert.Equal([]string{""}, adapterClusterRole.Rules[0].APIGroups, "ClusterRole apiGroups should be equal")
+	assert.Equal([]string{"pods", "endpoints", "services"}, adapterClusterRole.Rules[0].Resources, "ClusterRole resources should be equal")
+	assert.Equal([]string{"get", "list", "watch"}, adapterClusterRole.Rules[0].Verbs, "ClusterRole verbs should be equal")
+
+	var adapterClusterRoleBinding rbacv1.ClusterRoleBinding
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedRbacTemplate, "ClusterRoleBinding"), &adapterClusterRoleBinding)
+
+	assert.Equal(adapterClusterRole.Name, adapterClusterRoleBinding.RoleRef.Name, "ClusterRoleBinding should reference the ClusterRole")
+	require.Len(t, adapterClusterRoleBinding.Subjects, 1, "ClusterRoleBinding should have a single subject")
+	assert.Equal(namespace, adapterClusterRoleBinding.Subjects[0].Namespace, "ClusterRoleBinding subject namespace should be equal")
+
+	// The namespaced Role renders nothing, which helm reports as a missing template
+	_, err = renderTemplate(t, options, "templates/readyset-adapter-role.yaml")
+	require.Error(t, err, "The namespaced Role should not render in cluster scope mode")
+}
+
+func TestAdapterClientIdleTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.clientIdleTimeoutSeconds"] = "600"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	clientIdleTimeout, ok := getEnv(adapterContainer, "CLIENT_IDLE_TIMEOUT")
+	require.True(t, ok, "CLIENT_IDLE_TIMEOUT should be set")
+	assert.Equal("600", clientIdleTimeout.Value, "CLIENT_IDLE_TIMEOUT should equal 600")
+}
+
+func TestImageDigestPrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.image.repository"] = "registry.example.com/readyset/readyset-server"
+	chartValues["readyset.server.image.tag"] = "stable-230726"
+	chartValues["readyset.server.image.digest"] = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	chartValues["readyset.server.image.pullPolicy"] = "Always"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	assert.Equal("registry.example.com/readyset/readyset-server@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", serverContainer.Image, "Image should be pinned by digest")
+	assert.Equal(corev1.PullAlways, serverContainer.ImagePullPolicy, "Image pull policy should be equal")
+}
+
+func TestGlobalRegistryPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["image.registry"] = "mirror.example.com"
+	chartValues["readyset.server.image.tag"] = "stable-230726"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	assert.Equal("mirror.example.com/public.ecr.aws/readyset/readyset-server:stable-230726", serverContainer.Image, "Registry should be prepended to the server image")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	assert.True(strings.HasPrefix(adapterContainer.Image, "mirror.example.com/"), "Registry should be prepended to the adapter image")
+}
+
+func TestServerReplicationOnSchemaChange(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.replication.onSchemaChange"] = "resnapshot"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	onSchemaChange, ok := getEnv(serverContainer, "ON_SCHEMA_CHANGE")
+	require.True(t, ok, "ON_SCHEMA_CHANGE should be set")
+	assert.Equal(options.SetValues["readyset.server.replication.onSchemaChange"], onSchemaChange.Value, "ON_SCHEMA_CHANGE should equal 'resnapshot'")
+}
+
+func TestAdapterServiceAnnotationsPassThrough(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-service-annotations.yaml")
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	assert.Equal("true", adapterService.Annotations["ingress.example.com/default-backend"], "User annotations should be passed through")
+	assert.Equal("default-backend", adapterService.Annotations["readyset.io/component"], "User annotations should win on key conflicts")
+	assert.Equal(options.SetValues["readyset.deployment"], adapterService.Annotations["readyset.io/deployment"], "Chart annotations should still be present")
+}
+
+func TestInfoConfigMapReflectsValues(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.infoConfigMap.enabled"] = "true"
+	chartValues["readyset.queryCachingMode"] = "in-request-path"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var infoConfigMap corev1.ConfigMap
+
+	renderedConfigMapTemplate, err := renderTemplate(t, options, "templates/readyset-info-configmap.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedConfigMapTemplate, &infoConfigMap)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	queryCaching, ok := getEnv(adapterContainer, "QUERY_CACHING")
+	require.True(t, ok, "QUERY_CACHING should be set")
+	assert.Equal(queryCaching.Value, infoConfigMap.Data["queryCachingMode"], "queryCachingMode should match the adapter env")
+	assert.Equal(options.SetValues["readyset.queryCachingMode"], infoConfigMap.Data["queryCachingMode"], "queryCachingMode should equal 'in-request-path'")
+
+	deployment, ok := getEnv(adapterContainer, "DEPLOYMENT")
+	require.True(t, ok, "DEPLOYMENT should be set")
+	assert.Equal(deployment.Value, infoConfigMap.Data["deployment"], "deployment should match the adapter env")
+	assert.Equal("postgresql", infoConfigMap.Data["databaseType"], "databaseType should equal 'postgresql'")
+}
+
+func TestInfoConfigMapDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-info-configmap.yaml")
+	require.Error(t, err, "The readyset-info ConfigMap should not render by default")
+}
+
+func TestAuthorityTypeStandalone(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["consul.enabled"] = "false"
+	chartValues["readyset.authority.type"] = "standalone"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "charts/consul/templates/server-statefulset.yaml")
+	assert.Error(err, "Consul server StatefulSet should not be rendered with the standalone authority")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	authority, ok := getEnv(serverContainer, "AUTHORITY")
+	require.True(t, ok, "AUTHORITY should be set")
+	assert.Equal("standalone", authority.Value, "AUTHORITY should equal 'standalone'")
+
+	_, ok = findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "consul-agent")
+	assert.False(ok, "consul-agent sidecar should not be injected with the standalone authority")
+}
+
+func TestAuthorityTypeRejectsInvalid(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["consul.enabled"] = "false"
+	chartValues["readyset.authority.type"] = "etcd"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail for an unknown authority type")
+}
+
+func TestServerPvcRetentionPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.persistence.retentionPolicy.whenScaled"] = "Retain"
+	chartValues["readyset.server.persistence.retentionPolicy.whenDeleted"] = "Delete"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	retentionPolicy := serverStatefulSet.Spec.PersistentVolumeClaimRetentionPolicy
+	require.NotNil(t, retentionPolicy, "PVC retention policy should be set")
+	assert.Equal(appsv1.RetainPersistentVolumeClaimRetentionPolicyType, retentionPolicy.WhenScaled, "whenScaled should equal 'Retain'")
+	assert.Equal(appsv1.DeletePersistentVolumeClaimRetentionPolicyType, retentionPolicy.WhenDeleted, "whenDeleted should equal 'Delete'")
+}
+
+func TestAdapterMetricsLatencyBuckets(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-latency-buckets.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	latencyBuckets, ok := getEnv(adapterContainer, "METRICS_LATENCY_BUCKETS")
+	require.True(t, ok, "METRICS_LATENCY_BUCKETS should be set")
+	assert.Equal("0.005,0.01,0.25,1,2.5", latencyBuckets.Value, "METRICS_LATENCY_BUCKETS should be the comma-joined buckets")
+}
+
+func TestServerMemoryLimitExplicit(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.resources.limits.memory"] = "8Gi"
+	chartValues["readyset.server.memoryLimitDerivePercentage"] = "80"
+	chartValues["readyset.server.memoryLimitBytes"] = "6442450944"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	memoryLimit, ok := getEnv(serverContainer, "MEMORY_LIMIT")
+	require.True(t, ok, "MEMORY_LIMIT should be set")
+	assert.Equal(options.SetValues["readyset.server.memoryLimitBytes"], memoryLimit.Value, "The explicit memory limit should take precedence")
+}
+
+func TestServerMemoryLimitDerivedFromResources(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.resources.limits.memory"] = "8Gi"
+	chartValues["readyset.server.memoryLimitDerivePercentage"] = "80"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	memoryLimit, ok := getEnv(serverContainer, "MEMORY_LIMIT")
+	require.True(t, ok, "MEMORY_LIMIT should be set")
+	// 80% of 8Gi, rounded down
+	assert.Equal("6871947673", memoryLimit.Value, "MEMORY_LIMIT should be derived from the memory limit")
+}
+
+func TestServerStartupProbe(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.probes.startup.failureThreshold"] = "360"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	require.NotNil(t, serverContainer.StartupProbe, "readyset-server should have a startup probe")
+	assert.Equal(int32(360), serverContainer.StartupProbe.FailureThreshold, "Startup probe failure threshold should be equal")
+	assert.Equal(int32(10), serverContainer.StartupProbe.PeriodSeconds, "Startup probe period should be equal")
+}
+
+func TestServerStartupProbeDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.probes.startup.enabled"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	assert.Nil(serverContainer.StartupProbe, "readyset-server should not have a startup probe when disabled")
+}
+
+func TestServerDebugEndpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.debug.endpointEnabled"] = "true"
+	chartValues["readyset.server.debug.port"] = "6036"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	debugEnabled, ok := getEnv(serverContainer, "ENABLE_DEBUG_ENDPOINT")
+	require.True(t, ok, "ENABLE_DEBUG_ENDPOINT should be set when the debug endpoint is enabled")
+	assert.Equal("true", debugEnabled.Value, "ENABLE_DEBUG_ENDPOINT should equal 'true'")
+
+	var debugPort *corev1.ContainerPort
+	for i, p := range serverContainer.Ports {
+		if p.Name == "debug" {
+			debugPort = &serverContainer.Ports[i]
+		}
+	}
+	require.NotNil(t, debugPort, "readyset-server should expose a port named debug")
+	assert.Equal(int32(6036), debugPort.ContainerPort, "debug port should equal the configured port")
+}
+
+func TestServerSubdomain(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.subdomain"] = "readyset-pods"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(options.SetValues["readyset.server.subdomain"], serverStatefulSet.Spec.Template.Spec.Subdomain, "Pod subdomain should be equal")
+}
+
+func TestAdapterServiceLoadBalancer(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-service-loadbalancer.yaml")
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	assert.Equal(corev1.ServiceTypeLoadBalancer, adapterService.Spec.Type, "Service type should be LoadBalancer")
+	assert.Equal([]string{"10.0.0.0/8", "192.168.0.0/16"}, adapterService.Spec.LoadBalancerSourceRanges, "Source ranges should be equal")
+	assert.Equal(corev1.ServiceExternalTrafficPolicyLocal, adapterService.Spec.ExternalTrafficPolicy, "External traffic policy should be Local")
+	assert.Equal("internal", adapterService.Annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"], "Service annotations should be passed through")
+}
+
+func TestAdapterServiceNodePort(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.service.type"] = "NodePort"
+	chartValues["readyset.adapter.service.nodePort"] = "30432"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	assert.Equal(corev1.ServiceTypeNodePort, adapterService.Spec.Type, "Service type should be NodePort")
+
+	var sqlPort *corev1.ServicePort
+	for i, p := range adapterService.Spec.Ports {
+		if p.Port == 5432 {
+			sqlPort = &adapterService.Spec.Ports[i]
+		}
+	}
+	require.NotNil(t, sqlPort, "Adapter Service should expose the SQL port")
+	assert.Equal(int32(30432), sqlPort.NodePort, "nodePort should equal 30432")
+}
+
+func TestAdapterRevisionHistoryLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.revisionHistoryLimit"] = "5"
+	chartValues["readyset.server.revisionHistoryLimit"] = "7"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	require.NotNil(t, adapterDeployment.Spec.RevisionHistoryLimit, "Deployment revisionHistoryLimit should be set")
+	assert.Equal(int32(5), *adapterDeployment.Spec.RevisionHistoryLimit, "Deployment revisionHistoryLimit should be equal")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	require.NotNil(t, serverStatefulSet.Spec.RevisionHistoryLimit, "StatefulSet revisionHistoryLimit should be set")
+	assert.Equal(int32(7), *serverStatefulSet.Spec.RevisionHistoryLimit, "StatefulSet revisionHistoryLimit should be equal")
+}
+
+func TestRevisionHistoryLimitDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	require.NotNil(t, adapterDeployment.Spec.RevisionHistoryLimit, "Deployment revisionHistoryLimit should be set")
+	assert.Equal(int32(3), *adapterDeployment.Spec.RevisionHistoryLimit, "Deployment revisionHistoryLimit should default to 3")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	require.NotNil(t, serverStatefulSet.Spec.RevisionHistoryLimit, "StatefulSet revisionHistoryLimit should be set")
+	assert.Equal(int32(3), *serverStatefulSet.Spec.RevisionHistoryLimit, "StatefulSet revisionHistoryLimit should default to 3")
+}
+
+func TestRbacCreateDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["rbac.create"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-role.yaml")
+	require.Error(t, err, "The adapter Role should not render when rbac.create is false")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal("readyset-adapter", adapterDeployment.Spec.Template.Spec.ServiceAccountName, "Adapter pods should still run as their ServiceAccount")
+}
+
+func TestAdapterDefaultSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.defaultSchema"] = "app"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	defaultSchema, ok := getEnv(adapterContainer, "DEFAULT_SCHEMA")
+	require.True(t, ok, "DEFAULT_SCHEMA should be set")
+	assert.Equal(options.SetValues["readyset.adapter.defaultSchema"], defaultSchema.Value, "DEFAULT_SCHEMA should equal 'app'")
+}
+
+func TestServerExtraArgsAppended(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.extraArgs[0]"] = "--experimental-foo"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	// Containers[1] in this case is the container "readyset-server"
+	serverArgs := serverStatefulSet.Spec.Template.Spec.Containers[1].Args
+	require.NotEmpty(t, serverArgs, "readyset-server should have args")
+	assert.Equal("--experimental-foo", serverArgs[len(serverArgs)-1], "Extra args should be appended after the chart-managed args")
+}
+
+func TestServerExtraArgsDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	// Containers[1] in this case is the container "readyset-server"
+	assert.NotContains(serverStatefulSet.Spec.Template.Spec.Containers[1].Args, "--experimental-foo", "No extra args should be rendered by default")
+}
+
+func TestServerTmpVolumeMemoryBacked(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.tmpVolume.medium"] = "Memory"
+	chartValues["readyset.server.tmpVolume.sizeLimit"] = "2Gi"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var tmpVolume *corev1.Volume
+	for i, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		if v.Name == "tmp" {
+			tmpVolume = &serverStatefulSet.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, tmpVolume, "readyset-server should have a tmp volume")
+	require.NotNil(t, tmpVolume.EmptyDir, "tmp volume should be an emptyDir")
+	assert.Equal(corev1.StorageMediumMemory, tmpVolume.EmptyDir.Medium, "tmp volume should be memory-backed")
+	require.NotNil(t, tmpVolume.EmptyDir.SizeLimit, "tmp volume should have a size limit")
+	assert.Equal("2Gi", tmpVolume.EmptyDir.SizeLimit.String(), "tmp volume size limit should be equal")
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	var tmpMount *corev1.VolumeMount
+	for i, m := range serverContainer.VolumeMounts {
+		if m.Name == "tmp" {
+			tmpMount = &serverContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, tmpMount, "readyset-server should mount the tmp volume")
+	assert.Equal("/tmp", tmpMount.MountPath, "tmp volume mount path should be equal")
+}
+
+func TestServerTmpVolumeDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var tmpVolume *corev1.Volume
+	for i, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		if v.Name == "tmp" {
+			tmpVolume = &serverStatefulSet.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, tmpVolume, "readyset-server should have a tmp volume")
+	require.NotNil(t, tmpVolume.EmptyDir, "tmp volume should be an emptyDir")
+	assert.Equal(corev1.StorageMediumDefault, tmpVolume.EmptyDir.Medium, "tmp volume should be disk-backed by default")
+	assert.Nil(tmpVolume.EmptyDir.SizeLimit, "tmp volume should have no size limit by default")
+}
+
+func TestServerReplicationHeartbeatInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.replication.heartbeatIntervalSeconds"] = "15"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	heartbeatInterval, ok := getEnv(serverContainer, "REPLICATION_HEARTBEAT_INTERVAL")
+	require.True(t, ok, "REPLICATION_HEARTBEAT_INTERVAL should be set")
+	assert.Equal(options.SetValues["readyset.server.replication.heartbeatIntervalSeconds"], heartbeatInterval.Value, "REPLICATION_HEARTBEAT_INTERVAL should equal 15")
+}
+
+func TestServiceAccountLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.persistence.annotatePvcsJob.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/service-account-labels.yaml")
+
+	var serviceAccount corev1.ServiceAccount
+
+	renderedJobTemplate, err := renderTemplate(t, options, "templates/readyset-pvc-annotator-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedJobTemplate, "ServiceAccount"), &serviceAccount)
+
+	assert.Equal("data-platform", serviceAccount.Labels["owner"], "ServiceAccount label should be equal")
+	assert.Equal("1234", serviceAccount.Labels["cost-center"], "commonLabels should take precedence over ServiceAccount labels")
+	assert.Equal(options.SetValues["readyset.deployment"], serviceAccount.Labels["app.kubernetes.io/instance"], "app.kubernetes.io/instance should be equal")
+	assert.Equal(options.Version, serviceAccount.Labels["helm.sh/chart"], "Versions should be equal")
+}
+
+func TestServerHeadlessService(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var headlessService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-server-headless-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &headlessService)
+
+	assert.Equal("readyset-server-headless", headlessService.Name, "Headless Service name should be equal")
+	assert.Equal("None", headlessService.Spec.ClusterIP, "Headless Service should have no cluster IP")
+	assert.Equal(map[string]string{
+		"app.kubernetes.io/name":     "readyset-server",
+		"app.kubernetes.io/instance": options.SetValues["readyset.deployment"],
+	}, headlessService.Spec.Selector, "Headless Service should select the server pods")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(headlessService.Name, serverStatefulSet.Spec.ServiceName, "StatefulSet serviceName should match the headless Service")
+}
+
+func TestAdapterDnsConfigNdots(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-dns-config.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	podSpec := adapterDeployment.Spec.Template.Spec
+	assert.Equal(corev1.DNSClusterFirst, podSpec.DNSPolicy, "DNS policy should be equal")
+	require.NotNil(t, podSpec.DNSConfig, "Adapter pods should have a dnsConfig")
+	assert.Contains(podSpec.DNSConfig.Searches, "corp.example.com", "dnsConfig should contain the custom search domain")
+
+	var ndots *corev1.PodDNSConfigOption
+	for i, o := range podSpec.DNSConfig.Options {
+		if o.Name == "ndots" {
+			ndots = &podSpec.DNSConfig.Options[i]
+		}
+	}
+	require.NotNil(t, ndots, "dnsConfig should set the ndots option")
+	require.NotNil(t, ndots.Value, "ndots option should have a value")
+	assert.Equal("2", *ndots.Value, "ndots should equal 2")
+}
+
+func TestLoggingJsonWithTimestampFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.logging.format"] = "json"
+	chartValues["readyset.logging.timestampFormat"] = "rfc3339"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	for _, container := range []corev1.Container{adapterContainer, serverContainer} {
+		logFormat, ok := getEnv(container, "LOG_FORMAT")
+		require.True(t, ok, fmt.Sprintf("LOG_FORMAT should be set on %s", container.Name))
+		assert.Equal("json", logFormat.Value, "Log format should be equal")
+
+		timestampFormat, ok := getEnv(container, "LOG_TIMESTAMP_FORMAT")
+		require.True(t, ok, fmt.Sprintf("LOG_TIMESTAMP_FORMAT should be set on %s", container.Name))
+		assert.Equal("rfc3339", timestampFormat.Value, "Log timestamp format should be equal")
+	}
+}
+
+func TestServerJournalVolume(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.journal.enabled"] = "true"
+	chartValues["readyset.server.journal.size"] = "50Gi"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	require.Len(t, serverStatefulSet.Spec.VolumeClaimTemplates, 2, "StatefulSet should have a second volumeClaimTemplate")
+
+	var journalClaim *corev1.PersistentVolumeClaim
+	for i, c := range serverStatefulSet.Spec.VolumeClaimTemplates {
+		if c.Name == "journal" {
+			journalClaim = &serverStatefulSet.Spec.VolumeClaimTemplates[i]
+		}
+	}
+	require.NotNil(t, journalClaim, "StatefulSet should have a journal volumeClaimTemplate")
+	journalSize := journalClaim.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal("50Gi", journalSize.String(), "Journal volume size should be equal")
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	var journalMount *corev1.VolumeMount
+	for i, m := range serverContainer.VolumeMounts {
+		if m.Name == "journal" {
+			journalMount = &serverContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, journalMount, "readyset-server should mount the journal volume")
+	assert.Equal("/var/lib/readyset/journal", journalMount.MountPath, "Journal mount path should be equal")
+
+	journalDir, ok := getEnv(serverContainer, "JOURNAL_DIR")
+	require.True(t, ok, "JOURNAL_DIR should be set")
+	assert.Equal(journalMount.MountPath, journalDir.Value, "JOURNAL_DIR should equal the journal mount path")
+}
+
+func TestServerPrometheusEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.prometheus.enabled"] = "true"
+	chartValues["readyset.server.prometheus.address"] = "0.0.0.0:9092"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	prometheusMetrics, ok := getEnv(serverContainer, "PROMETHEUS_METRICS")
+	require.True(t, ok, "PROMETHEUS_METRICS should be set when enabled")
+	assert.Equal("true", prometheusMetrics.Value, "PROMETHEUS_METRICS should equal 'true'")
+
+	prometheusAddress, ok := getEnv(serverContainer, "PROMETHEUS_ADDRESS")
+	require.True(t, ok, "PROMETHEUS_ADDRESS should be set when enabled")
+	assert.Equal(options.SetValues["readyset.server.prometheus.address"], prometheusAddress.Value, "PROMETHEUS_ADDRESS should be equal")
+
+	var prometheusPort *corev1.ContainerPort
+	for i, p := range serverContainer.Ports {
+		if p.Name == "prometheus" {
+			prometheusPort = &serverContainer.Ports[i]
+		}
+	}
+	require.NotNil(t, prometheusPort, "readyset-server should expose a port named prometheus")
+	assert.Equal(int32(9092), prometheusPort.ContainerPort, "prometheus port should equal the configured port")
+
+	var headlessService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-server-headless-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &headlessService)
+
+	var servicePort *corev1.ServicePort
+	for i, p := range headlessService.Spec.Ports {
+		if p.Name == "prometheus" {
+			servicePort = &headlessService.Spec.Ports[i]
+		}
+	}
+	require.NotNil(t, servicePort, "Headless Service should expose the prometheus port")
+	assert.Equal(int32(9092), servicePort.Port, "Headless Service prometheus port should be equal")
+}
+
+func TestServerPrometheusDisabledByDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	for _, p := range serverContainer.Ports {
+		assert.NotEqual("prometheus", p.Name, "readyset-server should not expose a prometheus port by default")
+	}
+}
+
+func TestServerFsGroupChangePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.podSecurityContext.fsGroupChangePolicy"] = "Always"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	securityContext := serverStatefulSet.Spec.Template.Spec.SecurityContext
+	require.NotNil(t, securityContext, "readyset-server pods should have a security context")
+	require.NotNil(t, securityContext.FSGroupChangePolicy, "fsGroupChangePolicy should be set")
+	assert.Equal(corev1.FSGroupChangeAlways, *securityContext.FSGroupChangePolicy, "fsGroupChangePolicy should equal 'Always'")
+}
+
+func TestFsGroupChangePolicyRejectsInvalid(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.podSecurityContext.fsGroupChangePolicy"] = "Never"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail for an unknown fsGroupChangePolicy")
+}
+
+func TestAdapterDeploymentPaused(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.paused"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.True(adapterDeployment.Spec.Paused, "Adapter Deployment should be paused")
+}
+
+func TestServerMaxCachedQueries(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.maxCachedQueries"] = "1000"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	// The cap is independent from MEMORY_LIMIT, which keeps evicting below it
+	maxCachedQueries, ok := getEnv(serverContainer, "MAX_CACHED_QUERIES")
+	require.True(t, ok, "MAX_CACHED_QUERIES should be set")
+	assert.Equal(options.SetValues["readyset.server.maxCachedQueries"], maxCachedQueries.Value, "MAX_CACHED_QUERIES should equal 1000")
+
+	_, ok = getEnv(serverContainer, "MEMORY_LIMIT")
+	assert.False(ok, "MEMORY_LIMIT should not be implied by the cap")
+}
+
+func TestValuesSchemaRejectsBadCachingMode(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.queryCachingMode"] = "sometimes"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-info-configmap.yaml")
+	require.Error(t, err, "Rendering should fail for an unknown caching mode")
+	assert.Contains(err.Error(), "values don't meet the specifications of the schema", "The schema validation error should be surfaced")
+	assert.Contains(err.Error(), "queryCachingMode", "The schema validation error should name the offending value")
+}
+
+func TestValuesSchemaAcceptsValidValues(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.queryCachingMode"] = "async"
+	chartValues["readyset.adapter.type"] = "mysql"
+	chartValues["readyset.infoConfigMap.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-info-configmap.yaml")
+	require.NoError(t, err, "Valid values should pass the schema validation")
+}
+
+func TestServerPVCAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/server-pvc-metadata.yaml")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	require.NotEmpty(t, serverStatefulSet.Spec.VolumeClaimTemplates, "StatefulSet should have a volumeClaimTemplate")
+	claimMetadata := serverStatefulSet.Spec.VolumeClaimTemplates[0].ObjectMeta
+
+	assert.Equal("daily", claimMetadata.Annotations["backup-policy"], "volumeClaimTemplate annotation should be equal")
+	assert.Equal("data-platform", claimMetadata.Labels["team"], "volumeClaimTemplate label should be equal")
+
+	// Selector labels must not be overridden by readyset.server.persistence.labels
+	assert.Equal("helm-test-readyset", claimMetadata.Labels["app.kubernetes.io/instance"], "volumeClaimTemplate instance label should be equal")
+	for key, value := range serverStatefulSet.Spec.Selector.MatchLabels {
+		assert.Equal(value, serverStatefulSet.Spec.Template.Labels[key], "Pod template should still match the selector")
+	}
+}
+
+func TestAdapterTcpAndUnixListeners(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-listeners.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	var sqlPort *corev1.ContainerPort
+	for i, p := range adapterContainer.Ports {
+		if p.Name == "sql" {
+			sqlPort = &adapterContainer.Ports[i]
+		}
+	}
+	require.NotNil(t, sqlPort, "Adapter container should expose the TCP listener port")
+	assert.Equal(int32(5433), sqlPort.ContainerPort, "TCP listener port should be equal")
+
+	listenAddress, ok := getEnv(adapterContainer, "LISTEN_ADDRESS")
+	require.True(t, ok, "LISTEN_ADDRESS should be set")
+	assert.Equal("0.0.0.0:5433", listenAddress.Value, "LISTEN_ADDRESS should use the TCP listener port")
+
+	assert.Contains(adapterContainer.Args, "--unix-socket=/var/run/readyset/readyset.sock", "Adapter should be passed the Unix socket path")
+
+	var socketVolume *corev1.Volume
+	for i, v := range adapterDeployment.Spec.Template.Spec.Volumes {
+		if v.Name == "local-socket" {
+			socketVolume = &adapterDeployment.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, socketVolume, "Adapter pods should have a Unix socket volume")
+	assert.NotNil(socketVolume.EmptyDir, "Unix socket volume should be an emptyDir")
+
+	var socketMount *corev1.VolumeMount
+	for i, m := range adapterContainer.VolumeMounts {
+		if m.Name == "local-socket" {
+			socketMount = &adapterContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, socketMount, "Unix socket volume should be mounted")
+	assert.Equal("/var/run/readyset", socketMount.MountPath, "Unix socket volume should be mounted at the socket directory")
+}
+
+func TestAdapterShmVolume(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.shm.enabled"] = "true"
+	chartValues["readyset.adapter.shm.sizeLimit"] = "1Gi"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var shmVolume *corev1.Volume
+	for i, v := range adapterDeployment.Spec.Template.Spec.Volumes {
+		if v.Name == "shm" {
+			shmVolume = &adapterDeployment.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, shmVolume, "readyset-adapter should have a shm volume")
+	require.NotNil(t, shmVolume.EmptyDir, "shm volume should be an emptyDir")
+	assert.Equal(corev1.StorageMediumMemory, shmVolume.EmptyDir.Medium, "shm volume should be memory-backed")
+	require.NotNil(t, shmVolume.EmptyDir.SizeLimit, "shm volume should have a size limit")
+	assert.Equal("1Gi", shmVolume.EmptyDir.SizeLimit.String(), "shm volume size limit should be equal")
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	var shmMount *corev1.VolumeMount
+	for i, m := range adapterContainer.VolumeMounts {
+		if m.Name == "shm" {
+			shmMount = &adapterContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, shmMount, "readyset-adapter should mount the shm volume")
+	assert.Equal("/dev/shm", shmMount.MountPath, "shm volume mount path should be equal")
+}
+
+func TestAdapterShmVolumeDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	for _, v := range adapterDeployment.Spec.Template.Spec.Volumes {
+		assert.NotEqual(t, "shm", v.Name, "readyset-adapter should have no shm volume by default")
+	}
+
+	adapterContainer, ok := findContainer(adapterDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.True(t, ok, "readyset-adapter container should exist")
+
+	for _, m := range adapterContainer.VolumeMounts {
+		assert.NotEqual(t, "/dev/shm", m.MountPath, "Nothing should be mounted at /dev/shm by default")
+	}
+}
+
+func TestServerAutoTopologySpread(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.autoTopologySpread.enabled"] = "true"
+	chartValues["readyset.server.replicaCount"] = "3"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	require.NotNil(t, serverStatefulSet.Spec.Replicas, "StatefulSet should set replicas")
+	assert.Equal(int32(3), *serverStatefulSet.Spec.Replicas, "StatefulSet replicas should be equal")
+
+	var zoneConstraint *corev1.TopologySpreadConstraint
+	for i, c := range serverStatefulSet.Spec.Template.Spec.TopologySpreadConstraints {
+		if c.TopologyKey == "topology.kubernetes.io/zone" {
+			zoneConstraint = &serverStatefulSet.Spec.Template.Spec.TopologySpreadConstraints[i]
+		}
+	}
+	require.NotNil(t, zoneConstraint, "readyset-server pods should have a zone topology spread constraint")
+	assert.Equal(int32(1), zoneConstraint.MaxSkew, "maxSkew should equal 1")
+	require.NotNil(t, zoneConstraint.LabelSelector, "Zone constraint should have a label selector")
+	assert.Equal(serverStatefulSet.Spec.Selector.MatchLabels, zoneConstraint.LabelSelector.MatchLabels, "Zone constraint should select the readyset-server pods")
+}
+
+func TestServerCustomCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/server-command.yaml")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.Containers, "readyset-server")
+	require.True(t, ok, "readyset-server container should exist")
+
+	assert.Equal([]string{"/usr/local/bin/readyset-server-patched"}, serverContainer.Command, "Container command should equal the override")
+	require.NotEmpty(t, serverContainer.Args, "readyset-server should still have args")
+	assert.Equal("--experimental-foo", serverContainer.Args[len(serverContainer.Args)-1], "Extra args should still be passed with a custom command")
+}
+
+func TestServerPodLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/pod-labels.yaml")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	podLabels := serverStatefulSet.Spec.Template.Labels
+	assert.Equal("server", podLabels["readyset_role"], "readyset_role pod label should be equal")
+	assert.Equal("readyset", podLabels["readyset_component"], "readyset_component pod label should be equal")
+
+	selectorLabels := serverStatefulSet.Spec.Selector.MatchLabels
+	assert.NotContains(selectorLabels, "readyset_role", "podLabels should not be added to the selector")
+	assert.Equal("readyset-server", selectorLabels["app.kubernetes.io/name"], "Selector labels should be untouched")
+	for key, value := range selectorLabels {
+		assert.Equal(value, podLabels[key], "Pod template should still match the selector")
+	}
+}
+
+func TestAdapterPodLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/pod-labels.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	podLabels := adapterDeployment.Spec.Template.Labels
+	assert.Equal("adapter", podLabels["readyset_role"], "readyset_role pod label should be equal")
+	assert.Equal("readyset", podLabels["readyset_component"], "readyset_component pod label should be equal")
+
+	selectorLabels := adapterDeployment.Spec.Selector.MatchLabels
+	assert.NotContains(selectorLabels, "readyset_role", "podLabels should not be added to the selector")
+	assert.Equal("readyset-adapter", selectorLabels["app.kubernetes.io/name"], "Selector labels should be untouched")
+	for key, value := range selectorLabels {
+		assert.Equal(value, podLabels[key], "Pod template should still match the selector")
+	}
+}
+
+func TestServerHostAliases(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/server-host-aliases.yaml")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	hostAliases := serverStatefulSet.Spec.Template.Spec.HostAliases
+	require.Len(t, hostAliases, 1, "readyset-server pods should have one host alias")
+	assert.Equal("10.0.12.34", hostAliases[0].IP, "Host alias IP should be equal")
+	assert.Equal([]string{"db.internal.example.com", "db-replica.internal.example.com"}, hostAliases[0].Hostnames, "Host alias hostnames should be equal")
+}
+
+func TestServerHostAliasesDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Empty(t, serverStatefulSet.Spec.Template.Spec.HostAliases, "hostAliases should be omitted by default")
+}
+
+func TestServerPreloadCachesFromConfigMap(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.preloadCachesFrom.configMap.name"] = "readyset-caches"
+	chartValues["readyset.server.preloadCachesFrom.configMap.key"] = "caches.sql"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var preloadJob batchv1.Job
+
+	renderedJobTemplate, err := renderTemplate(t, options, "templates/readyset-preload-caches-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedJobTemplate, &preloadJob)
+
+	// The caches are created once the release is installed, and on every upgrade
+	assert.Equal("post-install,post-upgrade", preloadJob.Annotations["helm.sh/hook"], "Job should run after install and upgrade")
+
+	podSpec := preloadJob.Spec.Template.Spec
+
+	var cachesVolume *corev1.Volume
+	for i, v := range podSpec.Volumes {
+		if v.Name == "caches" {
+			cachesVolume = &podSpec.Volumes[i]
+		}
+	}
+	require.NotNil(t, cachesVolume, "Job should have a caches volume")
+	require.NotNil(t, cachesVolume.ConfigMap, "caches volume should be sourced from a ConfigMap")
+	assert.Equal("readyset-caches", cachesVolume.ConfigMap.Name, "ConfigMap name should be equal")
+
+	createContainer := requireContainer(t, podSpec, "create-caches")
+	require.NotEmpty(t, createContainer.Command, "create-caches container should have a command")
+	statement := createContainer.Command[len(createContainer.Command)-1]
+	assert.Contains(statement, "-h readyset-adapter", "Caches should be created through the adapter")
+	assert.Contains(statement, "-f /caches/caches.sql", "Statements should be read from the ConfigMap key")
+}
+
+func TestAdapterMemoryShedThreshold(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.memoryShedThresholdPercent"] = "85"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	shedThreshold, ok := getEnv(adapterContainer, "MEMORY_SHED_THRESHOLD_PERCENT")
+	require.True(t, ok, "MEMORY_SHED_THRESHOLD_PERCENT should be set")
+	assert.Equal("85", shedThreshold.Value, "MEMORY_SHED_THRESHOLD_PERCENT should equal 85")
+}
+
+func TestAdapterMemoryShedThresholdRejectsOutOfRange(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.memoryShedThresholdPercent"] = "101"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for a threshold above 100")
+}
+
+func TestAutomountDisabledServer(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.automountServiceAccountToken"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	automount := serverStatefulSet.Spec.Template.Spec.AutomountServiceAccountToken
+	require.NotNil(t, automount, "automountServiceAccountToken should be set on the server pods")
+	assert.False(*automount, "ServiceAccount token should not be mounted into the server pods")
+}
+
+func TestAutomountRbacConflict(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["rbac.create"] = "true"
+	chartValues["readyset.adapter.automountServiceAccountToken"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail when the adapter has RBAC permissions but no token to use them")
+}
+
+func TestServerPVCBackupTierLabel(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.persistence.labels.backup-tier"] = "gold"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	require.NotEmpty(t, serverStatefulSet.Spec.VolumeClaimTemplates, "StatefulSet should have a volumeClaimTemplate")
+	for _, claim := range serverStatefulSet.Spec.VolumeClaimTemplates {
+		assert.Equal("gold", claim.Labels["backup-tier"], fmt.Sprintf("volumeClaimTemplate %s should carry the backup-tier label", claim.Name))
+	}
+}
+
+func TestWarmupConfigMapAndJob(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/warmup-queries.yaml")
+
+	renderedWarmupTemplate, err := renderTemplate(t, options, "templates/readyset-warmup-job.yaml")
+	require.NoError(t, err)
+
+	var warmupConfigMap corev1.ConfigMap
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedWarmupTemplate, "ConfigMap"), &warmupConfigMap)
+
+	statements := warmupConfigMap.Data["warmup.sql"]
+	assert.Contains(statements, "CREATE CACHE FROM SELECT * FROM users WHERE id = $1;", "ConfigMap should hold the first query")
+	assert.Contains(statements, "CREATE CACHE FROM SELECT count(*) FROM orders WHERE customer_id = $1;", "ConfigMap should hold the second query")
+
+	var warmupJob batchv1.Job
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedWarmupTemplate, "Job"), &warmupJob)
+
+	assert.Equal("post-install,post-upgrade", warmupJob.Annotations["helm.sh/hook"], "Job should run after install and upgrade")
+
+	podSpec := warmupJob.Spec.Template.Spec
+
+	var cachesVolume *corev1.Volume
+	for i, v := range podSpec.Volumes {
+		if v.Name == "caches" {
+			cachesVolume = &podSpec.Volumes[i]
+		}
+	}
+	require.NotNil(t, cachesVolume, "Job should have a caches volume")
+	require.NotNil(t, cachesVolume.ConfigMap, "caches volume should be sourced from a ConfigMap")
+	assert.Equal(warmupConfigMap.Name, cachesVolume.ConfigMap.Name, "Job should reference the warmup ConfigMap")
+
+	createContainer := requireContainer(t, podSpec, "create-caches")
+	require.NotEmpty(t, createContainer.Command, "create-caches container should have a command")
+	statement := createContainer.Command[len(createContainer.Command)-1]
+	assert.Contains(statement, "-h readyset-adapter -p 5432", "Caches should be created through the adapter Service")
+	assert.Contains(statement, "-f /caches/warmup.sql", "Statements should be read from the ConfigMap")
+}
+
+func TestWarmupDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	// The template renders nothing unless readyset.warmup.enabled is true
+	_, err := renderTemplate(t, options, "templates/readyset-warmup-job.yaml")
+	require.Error(t, err, "Warmup resources should not be rendered by default")
+}
+
+func TestWarmupJobImage(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.waitForUpstream.enabled"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/warmup-queries.yaml")
+
+	renderedWarmupTemplate, err := renderTemplate(t, options, "templates/readyset-warmup-job.yaml")
+	require.NoError(t, err)
+
+	var warmupJob batchv1.Job
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedWarmupTemplate, "Job"), &warmupJob)
+
+	createContainer := requireContainer(t, warmupJob.Spec.Template.Spec, "create-caches")
+	assert.Equal("postgres:15-alpine", createContainer.Image, "Image should fall back to the waitForUpstream image")
+
+	chartValues["readyset.hooks.cachesImage"] = "postgres:16-alpine"
+
+	options = defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/warmup-queries.yaml")
+
+	renderedWarmupTemplate, err = renderTemplate(t, options, "templates/readyset-warmup-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedWarmupTemplate, "Job"), &warmupJob)
+
+	createContainer = requireContainer(t, warmupJob.Spec.Template.Spec, "create-caches")
+	assert.Equal("postgres:16-alpine", createContainer.Image, "Image should be equal")
+}
+
+func warmupCommand(t *testing.T, options *helm.Options) string {
+	renderedWarmupTemplate, err := renderTemplate(t, options, "templates/readyset-warmup-job.yaml")
+	require.NoError(t, err)
+
+	var warmupJob batchv1.Job
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedWarmupTemplate, "Job"), &warmupJob)
+
+	createContainer := requireContainer(t, warmupJob.Spec.Template.Spec, "create-caches")
+	require.NotEmpty(t, createContainer.Command, "create-caches container should have a command")
+	return createContainer.Command[len(createContainer.Command)-1]
+}
+
+func TestWarmupJobRetryConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.warmup.connectRetries"] = "10"
+	chartValues["readyset.warmup.retryIntervalSeconds"] = "3"
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/warmup-queries.yaml")
+
+	statement := warmupCommand(t, options)
+	assert.Contains(statement, "until pg_isready -h readyset-adapter -p 5432", "Job should wait for the adapter to accept connections")
+	assert.Contains(statement, "-ge 10 ]", "Job should give up after the configured number of attempts")
+	assert.Contains(statement, "sleep 3;", "Job should wait the configured interval between attempts")
+	assert.Contains(statement, "-f /caches/warmup.sql", "Job should create the caches once the adapter is reachable")
+}
+
+func TestWarmupJobRetryDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/warmup-queries.yaml")
+
+	statement := warmupCommand(t, options)
+	assert.Contains(statement, "-ge 30 ]", "Job should retry 30 times by default")
+	assert.Contains(statement, "sleep 5;", "Job should wait 5 seconds between attempts by default")
+}
+
+func TestAdapterClientTimeouts(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-client-timeouts.yaml")
+
+	var clientTimeoutsConfigMap corev1.ConfigMap
+
+	renderedConfigMapTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-client-timeouts-configmap.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedConfigMapTemplate, &clientTimeoutsConfigMap)
+
+	clientTimeouts := clientTimeoutsConfigMap.Data["client-timeouts.yaml"]
+	assert.Contains(clientTimeouts, "reporting: 120", "ConfigMap should hold the reporting client timeout")
+	assert.Contains(clientTimeouts, "checkout-api: 5", "ConfigMap should hold the checkout-api client timeout")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var clientTimeoutsVolume *corev1.Volume
+	for i, v := range adapterDeployment.Spec.Template.Spec.Volumes {
+		if v.Name == "client-timeouts" {
+			clientTimeoutsVolume = &adapterDeployment.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, clientTimeoutsVolume, "Adapter pods should have a client-timeouts volume")
+	require.NotNil(t, clientTimeoutsVolume.ConfigMap, "client-timeouts volume should be sourced from a ConfigMap")
+	assert.Equal(clientTimeoutsConfigMap.Name, clientTimeoutsVolume.ConfigMap.Name, "Volume should reference the client-timeouts ConfigMap")
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	var clientTimeoutsMount *corev1.VolumeMount
+	for i, m := range adapterContainer.VolumeMounts {
+		if m.Name == "client-timeouts" {
+			clientTimeoutsMount = &adapterContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, clientTimeoutsMount, "Adapter should mount the client-timeouts volume")
+
+	clientTimeoutsFile, ok := getEnv(adapterContainer, "CLIENT_TIMEOUTS_FILE")
+	require.True(t, ok, "CLIENT_TIMEOUTS_FILE should be set")
+	assert.Equal(clientTimeoutsMount.MountPath+"/client-timeouts.yaml", clientTimeoutsFile.Value, "CLIENT_TIMEOUTS_FILE should point into the mounted ConfigMap")
+}
+
+func TestAdapterPortNamesApplied(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.portNames.sql"] = "tcp-sql"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	var sqlContainerPort *corev1.ContainerPort
+	for i, p := range adapterContainer.Ports {
+		if p.Name == "tcp-sql" {
+			sqlContainerPort = &adapterContainer.Ports[i]
+		}
+	}
+	require.NotNil(t, sqlContainerPort, "Adapter SQL container port should be renamed")
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	var sqlServicePort *corev1.ServicePort
+	for i, p := range adapterService.Spec.Ports {
+		if p.Port == 5432 {
+			sqlServicePort = &adapterService.Spec.Ports[i]
+		}
+	}
+	require.NotNil(t, sqlServicePort, "Adapter Service should expose the SQL port")
+	assert.Equal(sqlContainerPort.Name, sqlServicePort.TargetPort.StrVal, "Service should target the renamed container port by name")
+}
+
+func TestPortNameTooLongFails(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.portNames.sql"] = "tcp-sql-connections"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for a port name longer than 15 characters")
+}
+
+// skipWithoutConsulChart skips a test rendering the bundled consul chart when the dependency has not been fetched
+// into charts/, as its templates cannot be rendered without it
+func skipWithoutConsulChart(t *testing.T) {
+	if matches, _ := filepath.Glob("charts/consul*"); len(matches) == 0 {
+		t.Skip("the consul dependency is not available under charts/")
+	}
+}
+
+func TestConsulPersistenceVolume(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["consul.server.storage"] = "5Gi"
+	chartValues["consul.server.storageClass"] = "gp3"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var consulStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "charts/consul/templates/server-statefulset.yaml")
+	skipWithoutConsulChart(t)
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &consulStatefulSet)
+
+	require.Len(t, consulStatefulSet.Spec.VolumeClaimTemplates, 1, "consul server should have a volumeClaimTemplate")
+	claim := consulStatefulSet.Spec.VolumeClaimTemplates[0]
+	claimSize := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal("5Gi", claimSize.String(), "consul data volume size should be equal")
+	require.NotNil(t, claim.Spec.StorageClassName, "consul data volume should set a storageClassName")
+	assert.Equal("gp3", *claim.Spec.StorageClassName, "consul data volume storageClassName should be equal")
+
+	for _, v := range consulStatefulSet.Spec.Template.Spec.Volumes {
+		if v.Name == claim.Name {
+			assert.Nil(v.EmptyDir, "consul data should not be on an emptyDir")
+		}
+	}
+}
+
+func TestOwnerReferenceOnWorkloads(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/owner-reference.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	for kind, ownerReferences := range map[string][]metav1.OwnerReference{
+		"Deployment":  adapterDeployment.OwnerReferences,
+		"StatefulSet": serverStatefulSet.OwnerReferences,
+	} {
+		require.Len(t, ownerReferences, 1, fmt.Sprintf("%s should have an ownerReference", kind))
+		assert.Equal("readyset.io/v1alpha1", ownerReferences[0].APIVersion, fmt.Sprintf("%s owner apiVersion should be equal", kind))
+		assert.Equal("ReadySetCluster", ownerReferences[0].Kind, fmt.Sprintf("%s owner kind should be equal", kind))
+		assert.Equal("production", ownerReferences[0].Name, fmt.Sprintf("%s owner name should be equal", kind))
+		assert.Equal("4f1b2c3d-5e6f-4a7b-8c9d-0e1f2a3b4c5d", string(ownerReferences[0].UID), fmt.Sprintf("%s owner uid should be equal", kind))
+		require.NotNil(t, ownerReferences[0].Controller, fmt.Sprintf("%s owner should set controller", kind))
+		assert.True(*ownerReferences[0].Controller, fmt.Sprintf("%s owner should be its controller", kind))
+	}
+}
+
+func TestPostUpgradeValidateHookJob(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.postUpgradeValidate.enabled"] = "true"
+	chartValues["readyset.server.image.tag"] = "stable-2023-09-01"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var postUpgradeJob batchv1.Job
+
+	renderedJobTemplate, err := renderTemplate(t, options, "templates/readyset-postupgrade-validate-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedJobTemplate, &postUpgradeJob)
+
+	assert.Equal("post-upgrade", postUpgradeJob.ObjectMeta.Annotations["helm.sh/hook"], "Job should run as a post-upgrade hook")
+
+	validateContainer := requireContainer(t, postUpgradeJob.Spec.Template.Spec, "postupgrade-validate")
+	assert.True(strings.HasSuffix(validateContainer.Image, ":"+options.SetValues["readyset.server.image.tag"]), "Job should use the new readyset-server image")
+}
+
+func TestPostUpgradeValidateHookJobDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-postupgrade-validate-job.yaml")
+	require.Error(t, err, "Post-upgrade Job should not be rendered by default")
+}
+
+func TestAdapterSidecarAppended(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+
+	containerIndex := func(containers []corev1.Container, name string) int {
+		for i, c := range containers {
+			if c.Name == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var defaultDeployment appsv1.Deployment
+
+	renderedDefaultTemplate, err := renderTemplate(t, defaultOptions(namespace, cliValues()), "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDefaultTemplate, &defaultDeployment)
+
+	options := defaultOptions(namespace, cliValues())
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-sidecars.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	podSpec := adapterDeployment.Spec.Template.Spec
+
+	sidecar := requireContainer(t, podSpec, "log-shipper")
+	assert.Equal("fluent/fluent-bit:2.1", sidecar.Image, "Sidecar image should be passed through")
+	assert.Equal("log-shipper", podSpec.Containers[len(podSpec.Containers)-1].Name, "Sidecars should be appended after the chart's containers")
+
+	defaultIndex := containerIndex(defaultDeployment.Spec.Template.Spec.Containers, "readyset-adapter")
+	require.NotEqual(t, -1, defaultIndex, "readyset-adapter container should exist")
+	assert.Equal(defaultIndex, containerIndex(podSpec.Containers, "readyset-adapter"), "readyset-adapter container index should be unchanged")
+
+	var initContainer *corev1.Container
+	for i, c := range podSpec.InitContainers {
+		if c.Name == "fetch-config" {
+			initContainer = &podSpec.InitContainers[i]
+		}
+	}
+	require.NotNil(t, initContainer, "Extra init container should be added")
+	assert.Equal([]string{"true"}, initContainer.Command, "Init container command should be passed through")
+}
+
+func TestAppVersionLabelTracksImageTag(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.image.tag"] = "stable-2023-10-01"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal("stable-2023-10-01", serverStatefulSet.ObjectMeta.Labels["app.kubernetes.io/version"], "Version label should track the image tag")
+	assert.NotContains(serverStatefulSet.Spec.Selector.MatchLabels, "app.kubernetes.io/version", "Version label should not be part of the selector")
+
+	helmChartPath, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	chart, err := loadChartYaml(helmChartPath)
+	require.NoError(t, err)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal(chart.Metadata.AppVersion, adapterDeployment.ObjectMeta.Labels["app.kubernetes.io/version"], "Adapter version label should not track the server image tag")
+
+	var headlessService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-server-headless-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &headlessService)
+
+	assert.Equal("stable-2023-10-01", headlessService.ObjectMeta.Labels["app.kubernetes.io/version"], "Version label of the other server resources should track the image tag too")
+}
+
+func TestAppVersionLabelDigestFallback(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.image.tag"] = "stable-2023-10-01"
+	chartValues["readyset.server.image.digest"] = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	options := defaultOptions(namespace, chartValues)
+
+	helmChartPath, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	chart, err := loadChartYaml(helmChartPath)
+	require.NoError(t, err)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(chart.Metadata.AppVersion, serverStatefulSet.ObjectMeta.Labels["app.kubernetes.io/version"], "Version label should fall back to the AppVersion with a digest")
+}
+
+func TestOpenMetricsExemplars(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.metrics.enabled"] = "true"
+	chartValues["readyset.metrics.serviceMonitor.enabled"] = "true"
+	chartValues["readyset.metrics.serviceMonitor.honorLabels"] = "true"
+	chartValues["readyset.metrics.openMetrics.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+	exemplars, ok := getEnv(adapterContainer, "METRICS_EXEMPLARS")
+	require.True(t, ok, "Adapter should have METRICS_EXEMPLARS set")
+	assert.Equal("true", exemplars.Value, "Adapter should enable exemplars")
+
+	// There are no prometheus-operator types among the test dependencies
+	var serviceMonitor struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			ScrapeProtocols []string `json:"scrapeProtocols"`
+			Endpoints       []struct {
+				Port        string `json:"port"`
+				HonorLabels bool   `json:"honorLabels"`
+			} `json:"endpoints"`
+		} `json:"spec"`
+	}
+
+	renderedServiceMonitorTemplate, err := renderTemplate(t, options, "templates/readyset-servicemonitor.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceMonitorTemplate, &serviceMonitor)
+
+	assert.Equal("ServiceMonitor", serviceMonitor.Kind)
+	require.NotEmpty(t, serviceMonitor.Spec.ScrapeProtocols, "ServiceMonitor should set the scrape protocols")
+	assert.Equal("OpenMetricsText1.0.0", serviceMonitor.Spec.ScrapeProtocols[0], "ServiceMonitor should prefer the OpenMetrics content type")
+	require.Len(t, serviceMonitor.Spec.Endpoints, 1)
+	assert.Equal("metrics", serviceMonitor.Spec.Endpoints[0].Port, "Endpoint should scrape the metrics port")
+	assert.True(serviceMonitor.Spec.Endpoints[0].HonorLabels, "Endpoint should honor the adapter's labels")
+}
+
+func TestServerFixPermissionsInitContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.fixPermissions.enabled"] = "true"
+	chartValues["readyset.server.podSecurityContext.runAsUser"] = "1000"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedTemplate, &serverStatefulSet)
+
+	initContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.InitContainers, "fix-permissions")
+	require.True(t, ok, "fix-permissions init container should exist when enabled")
+	assert.Equal([]string{"chown", "-R", "1000:1000", "/var/lib/readyset"}, initContainer.Command, "Init container should chown the data dir to the configured UID")
+	require.NotNil(t, initContainer.SecurityContext)
+	require.NotNil(t, initContainer.SecurityContext.RunAsUser)
+	assert.Equal(int64(0), *initContainer.SecurityContext.RunAsUser, "Init container should run as root")
+}
+
+func TestHookJobBackoffLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.hooks.preUpgradeCheck.enabled"] = "true"
+	chartValues["readyset.hooks.restartPolicy"] = "OnFailure"
+	chartValues["readyset.hooks.backoffLimit"] = "1"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var preUpgradeJob batchv1.Job
+
+	renderedJobTemplate, err := renderTemplate(t, options, "templates/readyset-preupgrade-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedJobTemplate, &preUpgradeJob)
+
+	require.NotNil(t, preUpgradeJob.Spec.BackoffLimit, "backoffLimit should be set")
+	assert.Equal(int32(1), *preUpgradeJob.Spec.BackoffLimit, "backoffLimit should be equal")
+	assert.Equal(corev1.RestartPolicyOnFailure, preUpgradeJob.Spec.Template.Spec.RestartPolicy, "restartPolicy should be equal")
+}
+
+func TestHookJobBackoffLimitDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.hooks.preUpgradeCheck.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var preUpgradeJob batchv1.Job
+
+	renderedJobTemplate, err := renderTemplate(t, options, "templates/readyset-preupgrade-job.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedJobTemplate, &preUpgradeJob)
+
+	require.NotNil(t, preUpgradeJob.Spec.BackoffLimit, "backoffLimit should be set")
+	assert.Equal(int32(3), *preUpgradeJob.Spec.BackoffLimit, "backoffLimit should default to 3")
+	assert.Equal(corev1.RestartPolicyNever, preUpgradeJob.Spec.Template.Spec.RestartPolicy, "restartPolicy should default to Never")
+
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/warmup-queries.yaml")
+
+	renderedWarmupTemplate, err := renderTemplate(t, options, "templates/readyset-warmup-job.yaml")
+	require.NoError(t, err)
+
+	var warmupJob batchv1.Job
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedWarmupTemplate, "Job"), &warmupJob)
+
+	require.NotNil(t, warmupJob.Spec.BackoffLimit, "backoffLimit should be set")
+	assert.Equal(int32(3), *warmupJob.Spec.BackoffLimit, "backoffLimit should default to 3")
+	assert.Equal(corev1.RestartPolicyNever, warmupJob.Spec.Template.Spec.RestartPolicy, "restartPolicy should default to Never")
+}
+
+func TestAdapterAuthModePostgres(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.auth.mode"] = "scram-sha-256"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	authMethod, ok := getEnv(adapterContainer, "AUTH_METHOD")
+	require.True(t, ok, "AUTH_METHOD should be set")
+	assert.Equal("scram-sha-256", authMethod.Value, "AUTH_METHOD should equal scram-sha-256")
+}
+
+func TestAdapterAuthModeRejectsMismatch(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.type"] = "postgresql"
+	chartValues["readyset.adapter.auth.mode"] = "mysql_native_password"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for a MySQL auth mode with a Postgres upstream")
+}
+
+func TestAdapterReadYourWrites(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.readYourWrites.enabled"] = "true"
+	chartValues["readyset.adapter.readYourWrites.windowMs"] = "500"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	readYourWrites, ok := getEnv(adapterContainer, "READ_YOUR_WRITES")
+	require.True(t, ok, "READ_YOUR_WRITES should be set")
+	assert.Equal("true", readYourWrites.Value, "READ_YOUR_WRITES should be enabled")
+
+	window, ok := getEnv(adapterContainer, "READ_YOUR_WRITES_WINDOW_MS")
+	require.True(t, ok, "READ_YOUR_WRITES_WINDOW_MS should be set")
+	assert.Equal("500", window.Value, "READ_YOUR_WRITES_WINDOW_MS should equal 500")
+}
+
+func TestAdapterReadYourWritesRejectsNegativeWindow(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.readYourWrites.enabled"] = "true"
+	chartValues["readyset.adapter.readYourWrites.windowMs"] = "-1"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for a negative window")
+}
+
+func TestServerMaintenanceCronJob(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.maintenance.enabled"] = "true"
+	chartValues["readyset.server.maintenance.schedule"] = "15 5 * * 6"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var maintenanceCronJob batchv1.CronJob
+
+	renderedCronJobTemplate, err := renderTemplate(t, options, "templates/readyset-server-maintenance-cronjob.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedCronJobTemplate, &maintenanceCronJob)
+
+	assert.Equal("15 5 * * 6", maintenanceCronJob.Spec.Schedule, "Schedules should be equal")
+
+	compactContainer, ok := findContainer(maintenanceCronJob.Spec.JobTemplate.Spec.Template.Spec.Containers, "compact")
+	require.True(t, ok, "compact container should exist")
+	require.NotEmpty(t, compactContainer.Command, "compact container should have a command")
+	assert.Equal("http://readyset-server:6033/compact", compactContainer.Command[len(compactContainer.Command)-1], "Compaction should be triggered on the readyset-server http port")
+}
+
+func TestEnvLookupByName(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.queryCachingMode"] = "async"
+	chartValues["readyset.server.replication_tables"] = "public.foo"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	queryCaching, ok := getEnv(adapterContainer, "QUERY_CACHING")
+	require.True(t, ok, "QUERY_CACHING should be set")
+	assert.Equal("async", queryCaching.Value, "QUERY_CACHING should be found by name")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	replicationTables, ok := getEnv(serverContainer, "REPLICATION_TABLES")
+	require.True(t, ok, "REPLICATION_TABLES should be set")
+	assert.Equal("public.foo", replicationTables.Value, "REPLICATION_TABLES should be found by name")
+}
+
+func TestAdapterDrainPreStop(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.drain.enabled"] = "true"
+	chartValues["readyset.adapter.drain.drainTimeoutSeconds"] = "45"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	require.NotNil(t, adapterContainer.Lifecycle, "Adapter should have a lifecycle")
+	require.NotNil(t, adapterContainer.Lifecycle.PreStop, "Adapter should have a preStop hook")
+	require.NotNil(t, adapterContainer.Lifecycle.PreStop.Exec, "preStop hook should exec a command")
+	preStop := strings.Join(adapterContainer.Lifecycle.PreStop.Exec.Command, " ")
+	assert.Contains(preStop, "http://127.0.0.1:6034/drain", "preStop hook should call the drain endpoint")
+	assert.Contains(preStop, "sleep 45", "preStop hook should wait for the drain timeout")
+
+	drainTimeout, ok := getEnv(adapterContainer, "DRAIN_TIMEOUT_SECONDS")
+	require.True(t, ok, "DRAIN_TIMEOUT_SECONDS should be set")
+	assert.Equal("45", drainTimeout.Value, "DRAIN_TIMEOUT_SECONDS should equal 45")
+
+	gracePeriod := adapterDeployment.Spec.Template.Spec.TerminationGracePeriodSeconds
+	require.NotNil(t, gracePeriod, "terminationGracePeriodSeconds should be set")
+	assert.Equal(int64(75), *gracePeriod, "Grace period should cover the drain timeout")
+}
+
+func TestAdapterDrainDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+	assert.Nil(t, adapterContainer.Lifecycle, "Adapter should not have a preStop hook by default")
+}
+
+func TestClusterSecretInjected(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.clusterSecret.existingSecret"] = "readyset-cluster-secret"
+	chartValues["readyset.clusterSecret.key"] = "secret"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	containers := []corev1.Container{
+		requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter"),
+		requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server"),
+	}
+	for _, container := range containers {
+		clusterSecret, ok := getEnv(container, "CLUSTER_SECRET")
+		require.True(t, ok, "CLUSTER_SECRET should be set on %s", container.Name)
+		require.NotNil(t, clusterSecret.ValueFrom, "CLUSTER_SECRET should be sourced from a secret on %s", container.Name)
+		require.NotNil(t, clusterSecret.ValueFrom.SecretKeyRef, "CLUSTER_SECRET should be sourced from a secret on %s", container.Name)
+		assert.Equal("readyset-cluster-secret", clusterSecret.ValueFrom.SecretKeyRef.Name, "Secret names should be equal on %s", container.Name)
+		assert.Equal("secret", clusterSecret.ValueFrom.SecretKeyRef.Key, "Secret keys should be equal on %s", container.Name)
+	}
+}
+
+func TestServerSnapshotRetention(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.persistence.snapshots.enabled"] = "true"
+	chartValues["readyset.server.persistence.snapshots.retain"] = "7"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var snapshotCronJob batchv1.CronJob
+
+	renderedTemplate, err := renderTemplate(t, options, "templates/readyset-server-snapshot-cronjob.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedTemplate, "CronJob"), &snapshotCronJob)
+
+	snapshotContainer, ok := findContainer(snapshotCronJob.Spec.JobTemplate.Spec.Template.Spec.Containers, "snapshot")
+	require.True(t, ok, "snapshot container should exist")
+
+	script := strings.Join(snapshotContainer.Command, " ")
+	assert.Contains(script, "kubectl create", "CronJob should create a new snapshot")
+	assert.Contains(script, "--sort-by=.metadata.creationTimestamp", "Snapshots should be pruned oldest first")
+	assert.Contains(script, "head -n -7", "CronJob should keep the 7 most recent snapshots")
+	assert.Contains(script, "kubectl delete", "CronJob should delete the snapshots beyond the retain count")
+}
+
+func TestServerSnapshotHost(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.upstream.snapshotHost"] = "db-replica.example.com"
+	chartValues["readyset.upstream.snapshotPort"] = "5433"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	snapshotHost, ok := getEnv(serverContainer, "SNAPSHOT_HOST")
+	require.True(t, ok, "SNAPSHOT_HOST should be set")
+	assert.Equal("db-replica.example.com", snapshotHost.Value, "SNAPSHOT_HOST should be the replica")
+
+	snapshotPort, ok := getEnv(serverContainer, "SNAPSHOT_PORT")
+	require.True(t, ok, "SNAPSHOT_PORT should be set")
+	assert.Equal("5433", snapshotPort.Value, "SNAPSHOT_PORT should be the replica's port")
+
+	upstreamURL, ok := getEnv(serverContainer, "UPSTREAM_DB_URL")
+	require.True(t, ok, "UPSTREAM_DB_URL should be set")
+	require.NotNil(t, upstreamURL.ValueFrom, "UPSTREAM_DB_URL should still be sourced from the primary's secret")
+	require.NotNil(t, upstreamURL.ValueFrom.SecretKeyRef, "UPSTREAM_DB_URL should still be sourced from the primary's secret")
+	assert.Equal("readyset-upstream-database", upstreamURL.ValueFrom.SecretKeyRef.Name, "Upstream secret name should be equal")
+}
+
+func TestServerSnapshotHostDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	_, ok := getEnv(serverContainer, "SNAPSHOT_HOST")
+	assert.False(ok, "SNAPSHOT_HOST should not be set, leaving the snapshot on the primary")
+
+	_, ok = getEnv(serverContainer, "UPSTREAM_DB_URL")
+	assert.True(ok, "UPSTREAM_DB_URL should be set")
+}
+
+func TestServerServiceAccountSeparate(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.serviceAccount.create"] = "true"
+	chartValues["readyset.server.serviceAccount.name"] = "readyset-server-identity"
+	chartValues["readyset.server.serviceAccount.annotations.iam\\.example\\.com/role"] = "readyset-server"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serviceAccount corev1.ServiceAccount
+
+	renderedServiceAccountTemplate, err := renderTemplate(t, options, "templates/readyset-server-serviceaccount.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceAccountTemplate, &serviceAccount)
+
+	assert.Equal("readyset-server-identity", serviceAccount.Name, "ServiceAccount names should be equal")
+	assert.Equal("readyset-server", serviceAccount.Annotations["iam.example.com/role"], "ServiceAccount annotation should be equal")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal("readyset-server-identity", serverStatefulSet.Spec.Template.Spec.ServiceAccountName, "Server pods should run as their own ServiceAccount")
+	assert.Equal("readyset-adapter", adapterDeployment.Spec.Template.Spec.ServiceAccountName, "Adapter pods should keep their ServiceAccount")
+}
+
+func TestAdapterProtocolVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.protocolVersion"] = "3.2"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	protocolVersion, ok := getEnv(adapterContainer, "PROTOCOL_VERSION")
+	require.True(t, ok, "PROTOCOL_VERSION should be set")
+	assert.Equal("3.2", protocolVersion.Value, "PROTOCOL_VERSION should equal 3.2")
+}
+
+func TestNetworkPolicyUpstreamSelector(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/network-policy-upstream-selector.yaml")
+
+	var networkPolicy networkingv1.NetworkPolicy
+
+	renderedTemplate, err := renderTemplate(t, options, "templates/readyset-networkpolicy.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedTemplate, &networkPolicy)
+
+	var upstreamRule *networkingv1.NetworkPolicyEgressRule
+	for i, rule := range networkPolicy.Spec.Egress {
+		for _, port := range rule.Ports {
+			if port.Port != nil && port.Port.IntValue() == 5432 {
+				upstreamRule = &networkPolicy.Spec.Egress[i]
+			}
+		}
+	}
+	require.NotNil(t, upstreamRule, "NetworkPolicy should allow egress to the upstream port")
+	require.Len(t, upstreamRule.To, 1, "Upstream egress should have a single peer")
+
+	peer := upstreamRule.To[0]
+	require.NotNil(t, peer.NamespaceSelector, "Upstream peer should have a namespaceSelector")
+	assert.Equal("databases", peer.NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"], "Namespace selector labels should be equal")
+	require.NotNil(t, peer.PodSelector, "Upstream peer should have a podSelector")
+	assert.Equal("postgresql", peer.PodSelector.MatchLabels["app.kubernetes.io/name"], "Pod selector labels should be equal")
+}
+
+func TestNetworkPolicyEgressToUpstreamCIDR(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/network-policy-upstream-cidr.yaml")
+
+	var networkPolicy networkingv1.NetworkPolicy
+
+	renderedTemplate, err := renderTemplate(t, options, "templates/readyset-networkpolicy.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedTemplate, &networkPolicy)
+
+	assert.Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, networkPolicy.Spec.PolicyTypes, "NetworkPolicy should only restrict egress")
+
+	var upstreamRule *networkingv1.NetworkPolicyEgressRule
+	for i, rule := range networkPolicy.Spec.Egress {
+		for _, port := range rule.Ports {
+			if port.Port != nil && port.Port.IntValue() == 6432 {
+				upstreamRule = &networkPolicy.Spec.Egress[i]
+			}
+		}
+	}
+	require.NotNil(t, upstreamRule, "NetworkPolicy should allow egress to the upstream port")
+	require.Len(t, upstreamRule.To, 1, "Upstream egress should have a single peer")
+	require.NotNil(t, upstreamRule.To[0].IPBlock, "Upstream peer should be an ipBlock")
+	assert.Equal("10.20.0.0/24", upstreamRule.To[0].IPBlock.CIDR, "Upstream CIDR should be equal")
+}
+
+func TestNetworkPolicyEgressAllowsDNS(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/network-policy-upstream-cidr.yaml")
+
+	var networkPolicy networkingv1.NetworkPolicy
+
+	renderedTemplate, err := renderTemplate(t, options, "templates/readyset-networkpolicy.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedTemplate, &networkPolicy)
+
+	dnsProtocols := map[corev1.Protocol]bool{}
+	for _, rule := range networkPolicy.Spec.Egress {
+		for _, port := range rule.Ports {
+			if port.Port != nil && port.Port.IntValue() == 53 && port.Protocol != nil {
+				dnsProtocols[*port.Protocol] = true
+			}
+		}
+	}
+	assert.True(dnsProtocols[corev1.ProtocolUDP], "NetworkPolicy should allow DNS over UDP")
+	assert.True(dnsProtocols[corev1.ProtocolTCP], "NetworkPolicy should allow DNS over TCP")
+}
+
+func TestPostgresReplicationSlotEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.type"] = "postgresql"
+	chartValues["readyset.upstream.postgres.replicationSlot"] = "readyset_orders"
+	chartValues["readyset.upstream.postgres.publication"] = "readyset_orders_pub"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	slot, ok := getEnv(serverContainer, "REPLICATION_SLOT_NAME")
+	require.True(t, ok, "REPLICATION_SLOT_NAME should be set")
+	assert.Equal("readyset_orders", slot.Value, "REPLICATION_SLOT_NAME should be equal")
+
+	publication, ok := getEnv(serverContainer, "PUBLICATION_NAME")
+	require.True(t, ok, "PUBLICATION_NAME should be set")
+	assert.Equal("readyset_orders_pub", publication.Value, "PUBLICATION_NAME should be equal")
+}
+
+func TestReplicationSlotIgnoredForMySQL(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.type"] = "mysql"
+	chartValues["readyset.upstream.postgres.replicationSlot"] = "readyset_orders"
+	chartValues["readyset.upstream.postgres.publication"] = "readyset_orders_pub"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	_, ok := getEnv(serverContainer, "REPLICATION_SLOT_NAME")
+	assert.False(ok, "REPLICATION_SLOT_NAME should not be set with a MySQL upstream")
+
+	_, ok = getEnv(serverContainer, "PUBLICATION_NAME")
+	assert.False(ok, "PUBLICATION_NAME should not be set with a MySQL upstream")
+}
+
+func TestServerExistingClaimSubPath(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.persistence.existingClaim"] = "shared-data"
+	chartValues["readyset.server.persistence.subPath"] = "readyset"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	for _, claim := range serverStatefulSet.Spec.VolumeClaimTemplates {
+		assert.NotEqual("state", claim.Name, "No volumeClaimTemplate should be provisioned for the data volume")
+	}
+
+	var stateVolume *corev1.Volume
+	for i, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		if v.Name == "state" {
+			stateVolume = &serverStatefulSet.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, stateVolume, "Server pods should have a state volume")
+	require.NotNil(t, stateVolume.PersistentVolumeClaim, "state volume should reference a PVC")
+	assert.Equal("shared-data", stateVolume.PersistentVolumeClaim.ClaimName, "state volume should reference the existing claim")
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	var stateMount *corev1.VolumeMount
+	for i, m := range serverContainer.VolumeMounts {
+		if m.Name == "state" {
+			stateMount = &serverContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, stateMount, "readyset-server should mount the state volume")
+	assert.Equal("readyset", stateMount.SubPath, "state mount should use the configured subPath")
+}
+
+func TestServerStateVolumeClaimTemplateDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var stateClaim *corev1.PersistentVolumeClaim
+	for i, claim := range serverStatefulSet.Spec.VolumeClaimTemplates {
+		if claim.Name == "state" {
+			stateClaim = &serverStatefulSet.Spec.VolumeClaimTemplates[i]
+		}
+	}
+	require.NotNil(t, stateClaim, "StatefulSet should provision the data volume by default")
+	assert.Equal("100Gi", stateClaim.Spec.Resources.Requests.Storage().String(), "Data volume size should be equal")
+
+	for _, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		assert.NotEqual("state", v.Name, "Server pods should not reference an existing claim by default")
+	}
+}
+
+func TestServerStatusFile(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.statusFile.enabled"] = "true"
+	chartValues["readyset.server.statusFile.path"] = "/var/run/readyset/replication.json"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var statusVolume *corev1.Volume
+	for i, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		if v.Name == "status" {
+			statusVolume = &serverStatefulSet.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, statusVolume, "Server pods should have a status volume")
+	assert.NotNil(statusVolume.EmptyDir, "status volume should be an emptyDir")
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	var statusMount *corev1.VolumeMount
+	for i, m := range serverContainer.VolumeMounts {
+		if m.Name == "status" {
+			statusMount = &serverContainer.VolumeMounts[i]
+		}
+	}
+	require.NotNil(t, statusMount, "readyset-server should mount the status volume")
+	assert.Equal("/var/run/readyset", statusMount.MountPath, "status volume should be mounted at the directory of the status file")
+
+	statusFile, ok := getEnv(serverContainer, "STATUS_FILE")
+	require.True(t, ok, "STATUS_FILE should be set")
+	assert.Equal("/var/run/readyset/replication.json", statusFile.Value, "STATUS_FILE should be equal")
+}
+
+func TestAdapterSoftAntiAffinityWithServer(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.antiAffinityWithServer"] = "soft"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	affinity := adapterDeployment.Spec.Template.Spec.Affinity
+	require.NotNil(t, affinity, "Adapter pods should have an affinity")
+	require.NotNil(t, affinity.PodAntiAffinity, "Adapter pods should have a podAntiAffinity")
+	assert.Empty(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, "soft anti-affinity should not be required")
+
+	preferred := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, preferred, 1, "Adapter pods should prefer a single anti-affinity term")
+
+	term := preferred[0].PodAffinityTerm
+	assert.Equal("kubernetes.io/hostname", term.TopologyKey, "Anti-affinity should spread across nodes")
+	require.NotNil(t, term.LabelSelector, "Anti-affinity term should have a label selector")
+	assert.Equal("readyset-server", term.LabelSelector.MatchLabels["app.kubernetes.io/name"], "Anti-affinity should select the server pods")
+	assert.Equal(options.SetValues["readyset.deployment"], term.LabelSelector.MatchLabels["app.kubernetes.io/instance"], "Anti-affinity should select this deployment's server pods")
+}
+
+func TestAdapterFallbackTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.fallback.enabled"] = "true"
+	chartValues["readyset.adapter.fallback.timeoutMs"] = "500"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	fallback, ok := getEnv(adapterContainer, "FALLBACK_ON_TIMEOUT")
+	require.True(t, ok, "FALLBACK_ON_TIMEOUT should be set")
+	assert.Equal("true", fallback.Value, "FALLBACK_ON_TIMEOUT should be enabled")
+
+	timeout, ok := getEnv(adapterContainer, "FALLBACK_TIMEOUT_MS")
+	require.True(t, ok, "FALLBACK_TIMEOUT_MS should be set")
+	assert.Equal("500", timeout.Value, "FALLBACK_TIMEOUT_MS should equal 500")
+}
+
+func TestAdapterFallbackTimeoutDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	_, ok := getEnv(adapterContainer, "FALLBACK_ON_TIMEOUT")
+	assert.False(ok, "FALLBACK_ON_TIMEOUT should not be set by default")
+
+	_, ok = getEnv(adapterContainer, "FALLBACK_TIMEOUT_MS")
+	assert.False(ok, "FALLBACK_TIMEOUT_MS should not be set by default")
+}
+
+func TestInternalOnlyForcesClusterIP(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.internalOnly"] = "true"
+	chartValues["readyset.adapter.ingressEnabled"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-service-loadbalancer.yaml")
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	assert.Equal(corev1.ServiceTypeClusterIP, adapterService.Spec.Type, "Service type should be forced to ClusterIP")
+	assert.Empty(adapterService.Spec.LoadBalancerSourceRanges, "LoadBalancer settings should be stripped")
+	assert.Empty(adapterService.Spec.ExternalTrafficPolicy, "External traffic policy should be stripped")
+}
+
+func TestInternalOnlyConflictsWithIngress(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.internalOnly"] = "true"
+	chartValues["readyset.adapter.ingressEnabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.Error(t, err, "Rendering should fail with both internalOnly and an Ingress enabled")
+}
+
+func TestFullnameOverrideCascades(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/fullname-override.yaml")
+
+	templates := []string{
+		"templates/readyset-adapter-deployment.yaml",
+		"templates/readyset-adapter-service.yaml",
+		"templates/readyset-adapter-role.yaml",
+		"templates/readyset-adapter-client-timeouts-configmap.yaml",
+		"templates/readyset-readonly-service.yaml",
+		"templates/readyset-metrics-service.yaml",
+		"templates/readyset-info-configmap.yaml",
+		"templates/readyset-server-statefulset.yaml",
+		"templates/readyset-server-headless-service.yaml",
+		"templates/readyset-server-serviceaccount.yaml",
+	}
+
+	rendered := map[string]string{}
+	for _, template := range templates {
+		renderedTemplate, err := renderTemplate(t, options, template)
+		require.NoError(t, err, "%s should render", template)
+		rendered[template] = renderedTemplate
+
+		for _, document := range strings.Split(renderedTemplate, "\n---") {
+			var object metav1.PartialObjectMetadata
+			helm.UnmarshalK8SYaml(t, document, &object)
+			assert.True(strings.HasPrefix(object.Name, "readyset-staging"), "%s %q from %s should share the fullname prefix", object.Kind, object.Name, template)
+		}
+	}
+
+	var adapterDeployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, rendered["templates/readyset-adapter-deployment.yaml"], &adapterDeployment)
+
+	var serverStatefulSet appsv1.StatefulSet
+	helm.UnmarshalK8SYaml(t, rendered["templates/readyset-server-statefulset.yaml"], &serverStatefulSet)
+
+	selects := func(service corev1.Service, podLabels map[string]string) {
+		require.NotEmpty(t, service.Spec.Selector, "%s should have a selector", service.Name)
+		for key, value := range service.Spec.Selector {
+			assert.Equal(value, podLabels[key], "%s selector should match the pod labels", service.Name)
+		}
+	}
+
+	for _, template := range []string{"templates/readyset-adapter-service.yaml", "templates/readyset-readonly-service.yaml", "templates/readyset-metrics-service.yaml"} {
+		var service corev1.Service
+		helm.UnmarshalK8SYaml(t, rendered[template], &service)
+		selects(service, adapterDeployment.Spec.Template.Labels)
+	}
+
+	var headlessService corev1.Service
+	helm.UnmarshalK8SYaml(t, rendered["templates/readyset-server-headless-service.yaml"], &headlessService)
+	selects(headlessService, serverStatefulSet.Spec.Template.Labels)
+	assert.Equal(headlessService.Name, serverStatefulSet.Spec.ServiceName, "StatefulSet serviceName should match the headless Service")
+}
+
+func TestEnvironmentLabelAndEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.environment"] = "prod"
+	chartValues["readyset.metrics.enabled"] = "true"
+	chartValues["readyset.metrics.serviceMonitor.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal("prod", adapterDeployment.Spec.Template.Labels["environment"], "Adapter pods should carry the environment label")
+	assert.Equal("prod", serverStatefulSet.Spec.Template.Labels["environment"], "Server pods should carry the environment label")
+
+	containers := []corev1.Container{
+		requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter"),
+		requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server"),
+	}
+	for _, container := range containers {
+		environment, ok := getEnv(container, "DEPLOYMENT_ENV")
+		require.True(t, ok, "DEPLOYMENT_ENV should be set on %s", container.Name)
+		assert.Equal("prod", environment.Value, "DEPLOYMENT_ENV should be equal on %s", container.Name)
+	}
+
+	// There are no prometheus-operator types among the test dependencies
+	var serviceMonitor struct {
+		Spec struct {
+			PodTargetLabels []string `json:"podTargetLabels"`
+		} `json:"spec"`
+	}
+
+	renderedServiceMonitorTemplate, err := renderTemplate(t, options, "templates/readyset-servicemonitor.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceMonitorTemplate, &serviceMonitor)
+
+	assert.Contains(serviceMonitor.Spec.PodTargetLabels, "environment", "ServiceMonitor should copy the environment label onto the metrics")
+}
+
+func TestInitContainerResourcesAndSecurityContext(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.waitForUpstream.resources.limits.cpu"] = "200m"
+	chartValues["readyset.server.waitForUpstream.resources.limits.memory"] = "32Mi"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	initContainer, ok := findContainer(serverStatefulSet.Spec.Template.Spec.InitContainers, "wait-for-upstream")
+	require.True(t, ok, "wait-for-upstream init container should exist by default")
+
+	assert.Equal("200m", initContainer.Resources.Limits.Cpu().String(), "CPU limit should be equal")
+	assert.Equal("32Mi", initContainer.Resources.Limits.Memory().String(), "Memory limit should be equal")
+
+	securityContext := initContainer.SecurityContext
+	require.NotNil(t, securityContext, "Init container should have a securityContext")
+	require.NotNil(t, securityContext.RunAsNonRoot, "runAsNonRoot should be set")
+	assert.True(*securityContext.RunAsNonRoot, "Init container should run as non-root")
+	require.NotNil(t, securityContext.RunAsUser, "runAsUser should be set")
+	assert.NotEqual(int64(0), *securityContext.RunAsUser, "Init container should not run as root")
+	require.NotNil(t, securityContext.AllowPrivilegeEscalation, "allowPrivilegeEscalation should be set")
+	assert.False(*securityContext.AllowPrivilegeEscalation, "Init container should not escalate privileges")
+}
+
+func TestServerConfigChecksumAnnotation(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+
+	renderChecksum := func(queryLog string) string {
+		chartValues := cliValues()
+
+		// Set values as though they are passed via the CLI
+		chartValues["readyset.server.useConfigFile"] = "true"
+		chartValues["readyset.server.config.query-log"] = queryLog
+
+		options := defaultOptions(namespace, chartValues)
+
+		var serverStatefulSet appsv1.StatefulSet
+
+		renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+		require.NoError(t, err)
+
+		helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+		checksum, ok := serverStatefulSet.Spec.Template.Annotations["checksum/config"]
+		require.True(t, ok, "Server pods should carry a checksum/config annotation")
+		assert.NotEmpty(checksum)
+
+		serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+		configFile, ok := getEnv(serverContainer, "CONFIG_FILE")
+		require.True(t, ok, "CONFIG_FILE should be set")
+		assert.Equal("/etc/readyset/config/config.yaml", configFile.Value)
+
+		return checksum
+	}
+
+	assert.NotEqual(renderChecksum("true"), renderChecksum("false"), "Changing the config should change the checksum")
+}
+
+func TestServerConfigChecksumAnnotationAbsentWithEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.NotContains(serverStatefulSet.Spec.Template.Annotations, "checksum/config", "checksum/config should only be set with useConfigFile")
+
+	_, err = renderTemplate(t, options, "templates/readyset-server-configmap.yaml")
+	require.Error(t, err, "The config ConfigMap should not render without useConfigFile")
+}
+
+func TestAdapterWorkerThreadsEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.workerThreads"] = "16"
+	chartValues["readyset.adapter.maxConnections"] = "2000"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	workerThreads, ok := getEnv(adapterContainer, "WORKER_THREADS")
+	require.True(t, ok, "WORKER_THREADS should be set")
+	assert.Equal("16", workerThreads.Value, "WORKER_THREADS should equal 16")
+
+	maxConnections, ok := getEnv(adapterContainer, "MAX_CONNECTIONS")
+	require.True(t, ok, "MAX_CONNECTIONS should be set")
+	assert.Equal("2000", maxConnections.Value, "MAX_CONNECTIONS should equal 2000")
+}
+
+func TestAdapterWorkerThreadsRejectsZero(t *testing.T) {
+	for _, key := range []string{"workerThreads", "maxConnections"} {
+		t.Run(key, func(t *testing.T) {
+			namespace := generateNamespaceName()
+			chartValues := cliValues()
+
+			// Set values as though they are passed via the CLI
+			chartValues["readyset.adapter."+key] = "0"
+
+			options := defaultOptions(namespace, chartValues)
+
+			_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+			require.Error(t, err, "Rendering should fail for a non-positive %s", key)
+		})
+	}
+}
+
+func TestReaderOnlyServerStatefulSet(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.readerPool.enabled"] = "true"
+	chartValues["readyset.server.readerPool.replicaCount"] = "3"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var readerStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-readers-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &readerStatefulSet)
+
+	require.NotNil(t, readerStatefulSet.Spec.Replicas)
+	assert.Equal(int32(3), *readerStatefulSet.Spec.Replicas, "Reader StatefulSet should have 3 replicas")
+	assert.Equal("readyset-server-reader", readerStatefulSet.Spec.Selector.MatchLabels["app.kubernetes.io/name"], "Readers should not be selected by the readyset-server StatefulSet")
+
+	readerContainer := requireContainer(t, readerStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	role, ok := getEnv(readerContainer, "SERVER_ROLE")
+	require.True(t, ok, "SERVER_ROLE should be set")
+	assert.Equal("reader", role.Value, "SERVER_ROLE should be reader")
+
+	for _, name := range []string{"UPSTREAM_DB_URL", "REPLICATION_SLOT_NAME", "PUBLICATION_NAME", "SNAPSHOT_HOST"} {
+		_, ok := getEnv(readerContainer, name)
+		assert.False(ok, "Readers should not set %s", name)
+	}
+}
+
+func TestReaderOnlyServerHeadlessService(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.readerPool.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var readerStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-readers-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &readerStatefulSet)
+
+	var readerService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-server-readers-headless-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &readerService)
+
+	assert.Equal(readerService.Name, readerStatefulSet.Spec.ServiceName, "Reader StatefulSet should be governed by the reader headless Service")
+	assert.Equal(corev1.ClusterIPNone, readerService.Spec.ClusterIP, "Reader Service should be headless")
+	assert.Equal(readerStatefulSet.Spec.Selector.MatchLabels, readerService.Spec.Selector, "Reader Service should select the reader pods")
+}
+
+func TestReaderOnlyServerStatefulSetDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-readers-statefulset.yaml")
+	require.Error(t, err, "The reader StatefulSet should not render by default")
+}
+
+func TestServerRuntimeClassName(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.runtimeClassName"] = "gvisor"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	runtimeClassName := serverStatefulSet.Spec.Template.Spec.RuntimeClassName
+	require.NotNil(t, runtimeClassName, "Server pods should set a runtimeClassName")
+	assert.Equal("gvisor", *runtimeClassName, "runtimeClassName should equal gvisor")
+}
+
+func TestAdapterRuntimeClassDefaultOmitted(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Nil(adapterDeployment.Spec.Template.Spec.RuntimeClassName, "Adapter pods should not set a runtimeClassName by default")
+}
+
+func TestAdapterDisabledServerOnly(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.enabled"] = "false"
+	chartValues["readyset.metrics.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	for _, template := range []string{
+		"templates/readyset-adapter-deployment.yaml",
+		"templates/readyset-adapter-service.yaml",
+		"templates/readyset-adapter-role.yaml",
+		"templates/readyset-metrics-service.yaml",
+	} {
+		_, err := renderTemplate(t, options, template)
+		require.Error(t, err, "%s should not render with the adapter disabled", template)
+	}
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+}
+
+func TestAdapterDisabledRejectsWarmup(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.enabled"] = "false"
+	chartValues["readyset.warmup.enabled"] = "true"
+	chartValues["readyset.warmup.queries[0]"] = "SELECT 1"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-warmup-job.yaml")
+	require.Error(t, err, "Rendering should fail with warmup enabled and the adapter disabled")
+
+	delete(chartValues, "readyset.warmup.enabled")
+	delete(chartValues, "readyset.warmup.queries[0]")
+	chartValues["readyset.server.preloadCachesFrom.url"] = "https://example.com/caches.sql"
+
+	options = defaultOptions(namespace, chartValues)
+
+	_, err = renderTemplate(t, options, "templates/readyset-preload-caches-job.yaml")
+	require.Error(t, err, "Rendering should fail with preloadCachesFrom set and the adapter disabled")
+}
+
+func TestAdapterDisabledNetworkPolicySelectsServerOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.enabled"] = "false"
+	chartValues["readyset.networkPolicy.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var networkPolicy networkingv1.NetworkPolicy
+
+	renderedNetworkPolicyTemplate, err := renderTemplate(t, options, "templates/readyset-networkpolicy.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedNetworkPolicyTemplate, &networkPolicy)
+
+	require.Len(t, networkPolicy.Spec.PodSelector.MatchExpressions, 1, "Pod selector should match on the component names")
+	assert.NotContains(networkPolicy.Spec.PodSelector.MatchExpressions[0].Values, "readyset-adapter", "Pod selector should not match the disabled adapter")
+	assert.Contains(networkPolicy.Spec.PodSelector.MatchExpressions[0].Values, "readyset-server", "Pod selector should match the server")
+}
+
+func TestBothDisabledFails(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.enabled"] = "false"
+	chartValues["readyset.server.enabled"] = "false"
+	chartValues["readyset.infoConfigMap.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-info-configmap.yaml")
+	require.Error(t, err, "Rendering should fail with both the adapter and the server disabled")
+}
+
+func TestServerProbeSchemeAndPath(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.probes.scheme"] = "HTTPS"
+	chartValues["readyset.server.probes.path"] = "/ready"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	require.NotNil(t, serverContainer.ReadinessProbe, "readyset-server should have a readiness probe")
+	require.NotNil(t, serverContainer.ReadinessProbe.HTTPGet, "Readiness probe should be an HTTP probe")
+	assert.Equal(corev1.URISchemeHTTPS, serverContainer.ReadinessProbe.HTTPGet.Scheme, "Readiness probe scheme should be HTTPS")
+	assert.Equal("/ready", serverContainer.ReadinessProbe.HTTPGet.Path, "Readiness probe path should be equal")
+
+	require.NotNil(t, serverContainer.StartupProbe, "readyset-server should have a startup probe")
+	require.NotNil(t, serverContainer.StartupProbe.HTTPGet, "Startup probe should be an HTTP probe")
+	assert.Equal(corev1.URISchemeHTTPS, serverContainer.StartupProbe.HTTPGet.Scheme, "Startup probe scheme should be HTTPS")
+	assert.Equal("/ready", serverContainer.StartupProbe.HTTPGet.Path, "Startup probe path should be equal")
+}
+
+func TestServerProbeSchemeAndPathDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	require.NotNil(t, serverContainer.ReadinessProbe, "readyset-server should have a readiness probe")
+	require.NotNil(t, serverContainer.ReadinessProbe.HTTPGet, "Readiness probe should be an HTTP probe")
+	assert.Equal(corev1.URISchemeHTTP, serverContainer.ReadinessProbe.HTTPGet.Scheme, "Readiness probe scheme should default to HTTP")
+	assert.Equal("/health", serverContainer.ReadinessProbe.HTTPGet.Path, "Readiness probe path should default to /health")
+}
+
+func TestAdapterServiceAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-service-nlb.yaml")
+
+	var adapterService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &adapterService)
+
+	assert.Equal("external", adapterService.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"], "NLB annotation should render")
+	assert.Equal("internal", adapterService.Annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"], "NLB scheme annotation should render")
+	assert.Equal("adapter", adapterService.Annotations["readyset.io/component"], "Chart annotations should still be present")
+}
+
+func TestMetricsServiceAnnotations(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/metrics-service-annotations.yaml")
+
+	var metricsService corev1.Service
+
+	renderedServiceTemplate, err := renderTemplate(t, options, "templates/readyset-metrics-service.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceTemplate, &metricsService)
+
+	assert.Equal("false", metricsService.Annotations["prometheus.io/scrape"], "Scrape annotation should render")
+	assert.Equal(options.SetValues["readyset.deployment"], metricsService.Annotations["readyset.io/deployment"], "Chart annotations should still be present")
+}
+
+func TestServerParallelPodManagement(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.podManagementPolicy"] = "Parallel"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(appsv1.ParallelPodManagement, serverStatefulSet.Spec.PodManagementPolicy, "podManagementPolicy should be Parallel")
+}
+
+func TestPodManagementPolicyRejectsInvalid(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.podManagementPolicy"] = "Ordered"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail for an unknown podManagementPolicy")
+}
+
+func TestAdapterMinReadySeconds(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.adapter.minReadySeconds"] = "15"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal(int32(15), adapterDeployment.Spec.MinReadySeconds, "minReadySeconds should equal 15")
+}
+
+func TestMinReadySecondsDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal(int32(0), adapterDeployment.Spec.MinReadySeconds, "Adapter minReadySeconds should default to 0")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(int32(0), serverStatefulSet.Spec.MinReadySeconds, "Server minReadySeconds should default to 0")
+}
+
+func TestAdapterEnvFromConfigMap(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-env-from.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	require.Len(t, adapterContainer.EnvFrom, 2, "readyset-adapter should inject both sources")
+	require.NotNil(t, adapterContainer.EnvFrom[0].ConfigMapRef, "First source should be a ConfigMap")
+	assert.Equal("readyset-tuning", adapterContainer.EnvFrom[0].ConfigMapRef.Name, "ConfigMap name should be equal")
+	require.NotNil(t, adapterContainer.EnvFrom[1].SecretRef, "Second source should be a Secret")
+	assert.Equal("readyset-tuning-secrets", adapterContainer.EnvFrom[1].SecretRef.Name, "Secret name should be equal")
+
+	_, ok := getEnv(adapterContainer, "LISTEN_ADDRESS")
+	assert.True(ok, "Chart-managed env should still be set alongside envFrom")
+}
+
+func TestAdapterEnvFromDefaultEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	adapterContainer := requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter")
+
+	assert.Empty(adapterContainer.EnvFrom, "envFrom should be empty when unset")
+}
+
+func TestConsulMTLSMounts(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/consul-mtls.yaml")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	secretVolumes := map[string]string{}
+	for _, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		if v.Secret != nil {
+			secretVolumes[v.Name] = v.Secret.SecretName
+		}
+	}
+	assert.Equal("consul-ca", secretVolumes["consul-ca"], "CA volume should use the CA Secret")
+	assert.Equal("readyset-consul-client-cert", secretVolumes["consul-cert"], "Cert volume should use the cert Secret")
+	assert.Equal("readyset-consul-client-key", secretVolumes["consul-key"], "Key volume should use the key Secret")
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	mounts := map[string]string{}
+	for _, m := range serverContainer.VolumeMounts {
+		mounts[m.Name] = m.MountPath
+	}
+
+	expectedEnv := map[string]string{
+		"CONSUL_CACERT":      mounts["consul-ca"] + "/ca.crt",
+		"CONSUL_CLIENT_CERT": mounts["consul-cert"] + "/tls.crt",
+		"CONSUL_CLIENT_KEY":  mounts["consul-key"] + "/tls.key",
+	}
+	for name, path := range expectedEnv {
+		envVar, ok := getEnv(serverContainer, name)
+		require.True(t, ok, "%s should be set", name)
+		assert.Equal(path, envVar.Value, "%s should point at the mounted file", name)
+	}
+
+	ssl, ok := getEnv(serverContainer, "CONSUL_HTTP_SSL")
+	require.True(t, ok, "CONSUL_HTTP_SSL should be set")
+	assert.Equal("true", ssl.Value, "CONSUL_HTTP_SSL should be enabled")
+}
+
+func TestConsulMTLSDisabledByDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	for _, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		assert.NotContains([]string{"consul-ca", "consul-cert", "consul-key"}, v.Name, "No Consul cert volumes should be rendered by default")
+	}
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+	_, ok := getEnv(serverContainer, "CONSUL_HTTP_SSL")
+	assert.False(ok, "CONSUL_HTTP_SSL should not be set by default")
+}
+
+// serviceMonitorEndpoints renders the ServiceMonitor and returns its endpoints; There are no prometheus-operator types
+// among the test dependencies
+func serviceMonitorEndpoints(t *testing.T, options *helm.Options) []map[string]interface{} {
+	var serviceMonitor struct {
+		Spec struct {
+			Endpoints []map[string]interface{} `json:"endpoints"`
+		} `json:"spec"`
+	}
+
+	renderedServiceMonitorTemplate, err := renderTemplate(t, options, "templates/readyset-servicemonitor.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedServiceMonitorTemplate, &serviceMonitor)
+
+	return serviceMonitor.Spec.Endpoints
+}
+
+func TestServiceMonitorRelabelings(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/service-monitor-relabelings.yaml")
+
+	endpoints := serviceMonitorEndpoints(t, options)
+	require.Len(t, endpoints, 1)
+	endpoint := endpoints[0]
+
+	assert.Equal(true, endpoint["honorLabels"], "Endpoint should honor the adapter's labels")
+
+	relabelings, ok := endpoint["relabelings"].([]interface{})
+	require.True(t, ok, "Endpoint should have relabelings")
+	require.Len(t, relabelings, 1)
+	assert.Equal("node", relabelings[0].(map[string]interface{})["targetLabel"], "Relabeling target label should be equal")
+
+	metricRelabelings, ok := endpoint["metricRelabelings"].([]interface{})
+	require.True(t, ok, "Endpoint should have metricRelabelings")
+	require.Len(t, metricRelabelings, 1)
+	assert.Equal("drop", metricRelabelings[0].(map[string]interface{})["action"], "Metric relabeling action should be equal")
+}
+
+func TestServiceMonitorRelabelingsOmittedByDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.metrics.enabled"] = "true"
+	chartValues["readyset.metrics.serviceMonitor.enabled"] = "true"
+
+	options := defaultOptions(namespace, chartValues)
+
+	endpoints := serviceMonitorEndpoints(t, options)
+	require.Len(t, endpoints, 1)
+
+	for _, field := range []string{"honorLabels", "relabelings", "metricRelabelings"} {
+		assert.NotContains(endpoints[0], field, "%s should be omitted by default", field)
+	}
+}
+
+func TestAdapterHTTPRoute(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-gateway-route.yaml")
+
+	// There are no Gateway API types among the test dependencies
+	var route struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			ParentRefs []struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"parentRefs"`
+			Hostnames []string `json:"hostnames"`
+			Rules     []struct {
+				Matches []struct {
+					Path struct {
+						Type  string `json:"type"`
+						Value string `json:"value"`
+					} `json:"path"`
+				} `json:"matches"`
+				BackendRefs []struct {
+					Name string `json:"name"`
+					Port int    `json:"port"`
+				} `json:"backendRefs"`
+			} `json:"rules"`
+		} `json:"spec"`
+	}
+
+	renderedTemplate, err := renderTemplate(t, options, "templates/readyset-httproute.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedTemplate, &route)
+
+	assert.Equal("HTTPRoute", route.Kind)
+	require.Len(t, route.Spec.ParentRefs, 1)
+	assert.Equal("internal-gateway", route.Spec.ParentRefs[0].Name, "parentRef name should be equal")
+	assert.Equal("gateways", route.Spec.ParentRefs[0].Namespace, "parentRef namespace should be equal")
+	assert.Equal([]string{"readyset.example.com"}, route.Spec.Hostnames, "Hostnames should be equal")
+
+	require.Len(t, route.Spec.Rules, 1)
+	require.Len(t, route.Spec.Rules[0].Matches, 1)
+	assert.Equal("/metrics", route.Spec.Rules[0].Matches[0].Path.Value, "Path match should be equal")
+	require.Len(t, route.Spec.Rules[0].BackendRefs, 1)
+	assert.Equal("readyset-adapter", route.Spec.Rules[0].BackendRefs[0].Name, "backendRef should be the adapter Service")
+	assert.Equal(6034, route.Spec.Rules[0].BackendRefs[0].Port, "backendRef should target the adapter HTTP port")
+}
+
+func TestAdapterHTTPRouteDisabledByDefault(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-httproute.yaml")
+	require.Error(t, err, "The HTTPRoute should not render by default")
+}
+
+func TestServerCommandOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	renderServerContainer := func(valuesFiles ...string) corev1.Container {
+		options := defaultOptions(namespace, chartValues)
+		options.ValuesFiles = append(options.ValuesFiles, valuesFiles...)
+
+		var serverStatefulSet appsv1.StatefulSet
+
+		renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+		require.NoError(t, err)
+
+		helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+		return requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+	}
+
+	defaultContainer := renderServerContainer()
+	serverContainer := renderServerContainer("testdata/server-debug-command.yaml")
+
+	assert.Equal([]string{"sleep", "infinity"}, serverContainer.Command, "Container command should equal the override")
+	assert.Equal(defaultContainer.Args, serverContainer.Args, "Chart-managed args should remain intact")
+}
+
+func TestServerCommandDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	assert.Empty(serverContainer.Command, "The image entrypoint should apply by default")
+}
+
+func TestServerArgsOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.command"] = "{sleep}"
+	chartValues["readyset.server.args"] = "{infinity}"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	assert.Equal([]string{"sleep"}, serverContainer.Command, "Container command should equal the override")
+	assert.Equal([]string{"infinity"}, serverContainer.Args, "Container args should equal the override")
+}
+
+func TestAdapterReadinessGates(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/adapter-readiness-gates.yaml")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	readinessGates := adapterDeployment.Spec.Template.Spec.ReadinessGates
+	require.Len(t, readinessGates, 1, "Adapter pods should have a readiness gate")
+	assert.Equal(corev1.PodConditionType("target-health.elbv2.k8s.aws/readyset-adapter-tg"), readinessGates[0].ConditionType, "Readiness gate condition type should be equal")
+}
+
+func TestAdapterReadinessGatesDefaultOmitted(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Empty(adapterDeployment.Spec.Template.Spec.ReadinessGates, "Adapter pods should not have readiness gates by default")
+}
+
+func TestReplicationIncludeExcludePrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/replication-include-exclude.yaml")
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	tables, ok := getEnv(serverContainer, "REPLICATION_TABLES")
+	require.True(t, ok, "REPLICATION_TABLES should be set")
+	assert.Equal("public.*", tables.Value, "Excluded entries should be dropped from REPLICATION_TABLES")
+
+	ignore, ok := getEnv(serverContainer, "REPLICATION_TABLES_IGNORE")
+	require.True(t, ok, "REPLICATION_TABLES_IGNORE should be set")
+	assert.Equal("public.events,audit.*", ignore.Value, "REPLICATION_TABLES_IGNORE should list the exclusions")
+}
+
+func TestReplicationEntryFormatValidation(t *testing.T) {
+	for _, entry := range []string{"public", "public.events.id", "public.", "*.events"} {
+		t.Run(entry, func(t *testing.T) {
+			namespace := generateNamespaceName()
+			chartValues := cliValues()
+
+			// Set values as though they are passed via the CLI
+			chartValues["readyset.server.replication.include"] = "{" + entry + "}"
+
+			options := defaultOptions(namespace, chartValues)
+
+			_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+			require.Error(t, err, "Rendering should fail for the malformed entry %q", entry)
+		})
+	}
+}
+
+func TestServerPodOrdinalEnv(t *testing.T) {
+	renderServerPodSpec := func(label string) corev1.PodSpec {
+		namespace := generateNamespaceName()
+		chartValues := cliValues()
+
+		// Set values as though they are passed via the CLI
+		chartValues["readyset.server.podOrdinal.label"] = label
+
+		options := defaultOptions(namespace, chartValues)
+
+		var serverStatefulSet appsv1.StatefulSet
+
+		renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+		require.NoError(t, err)
+
+		helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+		return serverStatefulSet.Spec.Template.Spec
+	}
+
+	for _, label := range []string{"true", "false"} {
+		t.Run("label="+label, func(t *testing.T) {
+			assert := assert.New(t)
+
+			podSpec := renderServerPodSpec(label)
+			serverContainer := requireContainer(t, podSpec, "readyset-server")
+
+			podName, ok := getEnv(serverContainer, "POD_NAME")
+			require.True(t, ok, "POD_NAME should be set")
+			require.NotNil(t, podName.ValueFrom, "POD_NAME should come from the downward API")
+			require.NotNil(t, podName.ValueFrom.FieldRef, "POD_NAME should reference a pod field")
+			assert.Equal("metadata.name", podName.ValueFrom.FieldRef.FieldPath, "POD_NAME should reference metadata.name")
+
+			podOrdinal, ok := getEnv(serverContainer, "POD_ORDINAL")
+			require.True(t, ok, "POD_ORDINAL should always be set")
+			require.NotNil(t, podOrdinal.ValueFrom, "POD_ORDINAL should come from the downward API")
+			require.NotNil(t, podOrdinal.ValueFrom.FieldRef, "POD_ORDINAL should reference a pod field")
+			assert.Equal("metadata.name", podOrdinal.ValueFrom.FieldRef.FieldPath, "POD_ORDINAL should be derived from metadata.name")
+
+			labelContainer, ok := findContainer(podSpec.InitContainers, "label-pod-ordinal")
+			if label == "false" {
+				assert.False(ok, "Pods should not be labelled without podOrdinal.label")
+				return
+			}
+			require.True(t, ok, "Pods should be labelled with podOrdinal.label")
+			assert.Contains(labelContainer.Command[len(labelContainer.Command)-1], "readyset.io/pod-ordinal=\"${POD_NAME##*-}\"", "The label should project the ordinal")
+		})
+	}
+}
+
+func TestServerPodOrdinalLabelRole(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-role.yaml")
+	require.Error(t, err, "The readyset-server Role should not render by default")
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.podOrdinal.label"] = "true"
+
+	options = defaultOptions(namespace, chartValues)
+
+	renderedRoleTemplate, err := renderTemplate(t, options, "templates/readyset-server-role.yaml")
+	require.NoError(t, err)
+
+	var role rbacv1.Role
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedRoleTemplate, "Role"), &role)
+
+	require.NotEmpty(t, role.Rules, "Role should have rules")
+	assert.Contains(t, role.Rules[0].Verbs, "patch", "Role should allow labelling pods")
+
+	var roleBinding rbacv1.RoleBinding
+
+	helm.UnmarshalK8SYaml(t, findDocument(t, renderedRoleTemplate, "RoleBinding"), &roleBinding)
+
+	require.Len(t, roleBinding.Subjects, 1, "RoleBinding should have one subject")
+	assert.Equal(t, "readyset-server", roleBinding.Subjects[0].Name, "The Role should be bound to the dedicated readyset-server ServiceAccount")
+
+	renderedServiceAccountTemplate, err := renderTemplate(t, options, "templates/readyset-server-serviceaccount.yaml")
+	require.NoError(t, err, "The readyset-server ServiceAccount should render with podOrdinal.label")
+
+	var serviceAccount corev1.ServiceAccount
+
+	helm.UnmarshalK8SYaml(t, renderedServiceAccountTemplate, &serviceAccount)
+
+	assert.Equal(t, "readyset-server", serviceAccount.Name, "ServiceAccount name should be the readyset-server fullname")
+}
+
+func TestServerPodOrdinalLabelRequiresAutomount(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.podOrdinal.label"] = "true"
+	chartValues["readyset.server.automountServiceAccountToken"] = "false"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail when the ordinal labelling has no token to patch its pod with")
+}
+
+func TestNodeNamePinning(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.nodeName"] = "kind-control-plane"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal("kind-control-plane", serverStatefulSet.Spec.Template.Spec.NodeName, "Server pods should be pinned to the node")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal("kind-control-plane", adapterDeployment.Spec.Template.Spec.NodeName, "Adapter pods should be pinned to the node")
+}
+
+func TestNodeNameDefaultOmitted(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Empty(serverStatefulSet.Spec.Template.Spec.NodeName, "Server pods should be scheduled by default")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Empty(adapterDeployment.Spec.Template.Spec.NodeName, "Adapter pods should be scheduled by default")
+}
+
+func TestNodeNameConflictsWithHardAntiAffinity(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.nodeName"] = "kind-control-plane"
+	chartValues["readyset.adapter.antiAffinityWithServer"] = "hard"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for nodeName with a hard anti-affinity")
+}
+
+func TestNodeSelector(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.server.nodeSelector.disktype"] = "ssd"
+	chartValues["readyset.adapter.nodeSelector.pool"] = "adapters"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	assert.Equal(map[string]string{"disktype": "ssd"}, serverStatefulSet.Spec.Template.Spec.NodeSelector, "Server pods should have the nodeSelector")
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	assert.Equal(map[string]string{"pool": "adapters"}, adapterDeployment.Spec.Template.Spec.NodeSelector, "Adapter pods should have the nodeSelector")
+}
+
+func TestNodeNameConflictsWithNodeSelector(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.nodeName"] = "kind-control-plane"
+	chartValues["readyset.server.nodeSelector.disktype"] = "ssd"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail for nodeName with a nodeSelector")
+}
+
+func TestTracingEnvVars(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.tracing.enabled"] = "true"
+	chartValues["readyset.tracing.endpoint"] = "http://otel-collector.observability:4317"
+	chartValues["readyset.tracing.samplingRatio"] = "0.25"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	containers := []corev1.Container{
+		requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server"),
+		requireContainer(t, adapterDeployment.Spec.Template.Spec, "readyset-adapter"),
+	}
+	for _, container := range containers {
+		t.Run(container.Name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			endpoint, ok := getEnv(container, "TRACING_ENDPOINT")
+			require.True(t, ok, "TRACING_ENDPOINT should be set")
+			assert.Equal("http://otel-collector.observability:4317", endpoint.Value, "TRACING_ENDPOINT should be equal")
+
+			ratio, ok := getEnv(container, "TRACING_SAMPLE_RATIO")
+			require.True(t, ok, "TRACING_SAMPLE_RATIO should be set")
+			assert.Equal("0.25", ratio.Value, "TRACING_SAMPLE_RATIO should be equal")
+
+			serviceName, ok := getEnv(container, "TRACING_SERVICE_NAME")
+			require.True(t, ok, "TRACING_SERVICE_NAME should be set")
+			assert.Equal(container.Name, serviceName.Value, "TRACING_SERVICE_NAME should name the component")
+		})
+	}
+}
+
+func TestTracingSamplingRatioValidation(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.tracing.enabled"] = "true"
+	chartValues["readyset.tracing.endpoint"] = "http://otel-collector.observability:4317"
+	chartValues["readyset.tracing.samplingRatio"] = "1.5"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for a sampling ratio above 1")
+
+	chartValues["readyset.tracing.samplingRatio"] = "half"
+
+	options = defaultOptions(namespace, chartValues)
+
+	_, err = renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.Error(t, err, "Rendering should fail for a non-numeric sampling ratio")
+}
+
+func TestUpstreamReconnectBackoffEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.upstream.reconnect.initialBackoffSeconds"] = "2"
+	chartValues["readyset.upstream.reconnect.maxBackoffSeconds"] = "60"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	initialBackoff, ok := getEnv(serverContainer, "UPSTREAM_RECONNECT_INITIAL_BACKOFF_SECONDS")
+	require.True(t, ok, "UPSTREAM_RECONNECT_INITIAL_BACKOFF_SECONDS should be set")
+	assert.Equal("2", initialBackoff.Value, "Initial reconnect backoff should be equal")
+
+	maxBackoff, ok := getEnv(serverContainer, "UPSTREAM_RECONNECT_MAX_BACKOFF_SECONDS")
+	require.True(t, ok, "UPSTREAM_RECONNECT_MAX_BACKOFF_SECONDS should be set")
+	assert.Equal("60", maxBackoff.Value, "Maximum reconnect backoff should be equal")
+}
+
+func TestReconnectBackoffValidation(t *testing.T) {
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.upstream.reconnect.initialBackoffSeconds"] = "90"
+	chartValues["readyset.upstream.reconnect.maxBackoffSeconds"] = "60"
+
+	options := defaultOptions(namespace, chartValues)
+
+	_, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.Error(t, err, "Rendering should fail for an initial backoff above the maximum")
+}
+
+// namespaceOverrideTemplates renders a mix of the chart's namespaced resources when combined with
+// testdata/namespace-override.yaml
+var namespaceOverrideTemplates = map[string][]string{
+	"templates/readyset-info-configmap.yaml":          {"ConfigMap"},
+	"templates/readyset-metrics-service.yaml":         {"Service"},
+	"templates/readyset-networkpolicy.yaml":           {"NetworkPolicy"},
+	"templates/readyset-server-configmap.yaml":        {"ConfigMap"},
+	"templates/readyset-server-headless-service.yaml": {"Service"},
+	"templates/readyset-server-serviceaccount.yaml":   {"ServiceAccount"},
+	"templates/readyset-warmup-job.yaml":              {"ConfigMap", "Job"},
+}
+
+func TestNamespaceOverrideAppliedEverywhere(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/namespace-override.yaml")
+
+	for templateFile, kinds := range namespaceOverrideTemplates {
+		rendered, err := renderTemplate(t, options, templateFile)
+		require.NoError(t, err, templateFile)
+
+		for _, kind := range kinds {
+			var object metav1.PartialObjectMetadata
+
+			helm.UnmarshalK8SYaml(t, findDocument(t, rendered, kind), &object)
+			assert.Equal("readyset-prod", object.Namespace, "%s in %s should be rendered into the override namespace", kind, templateFile)
+		}
+	}
+}
+
+func TestNamespaceDefaultsToReleaseNamespace(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.namespaceOverride"] = ""
+
+	options := defaultOptions(namespace, chartValues)
+	options.ValuesFiles = append(options.ValuesFiles, "testdata/namespace-override.yaml")
+
+	for templateFile, kinds := range namespaceOverrideTemplates {
+		rendered, err := renderTemplate(t, options, templateFile)
+		require.NoError(t, err, templateFile)
+
+		for _, kind := range kinds {
+			var object metav1.PartialObjectMetadata
+
+			helm.UnmarshalK8SYaml(t, findDocument(t, rendered, kind), &object)
+			assert.Equal(namespace, object.Namespace, "%s in %s should be rendered into the release namespace", kind, templateFile)
+		}
+	}
+}
+
+func TestTrustedCABundleMountAndEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	// Set values as though they are passed via the CLI
+	chartValues["readyset.trustedCABundle.secret"] = "corporate-ca-bundle"
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	var adapterDeployment appsv1.Deployment
+
+	renderedDeploymentTemplate, err := renderTemplate(t, options, "templates/readyset-adapter-deployment.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedDeploymentTemplate, &adapterDeployment)
+
+	podSpecs := map[string]corev1.PodSpec{
+		"readyset-server":  serverStatefulSet.Spec.Template.Spec,
+		"readyset-adapter": adapterDeployment.Spec.Template.Spec,
+	}
+	for name, podSpec := range podSpecs {
+		var caVolume *corev1.Volume
+		for i, v := range podSpec.Volumes {
+			if v.Name == "trusted-ca-bundle" {
+				caVolume = &podSpec.Volumes[i]
+			}
+		}
+		require.NotNil(t, caVolume, "%s should have a trusted-ca-bundle volume", name)
+		require.NotNil(t, caVolume.Secret, "trusted-ca-bundle volume of %s should be sourced from a Secret", name)
+		assert.Equal("corporate-ca-bundle", caVolume.Secret.SecretName, "trusted-ca-bundle volume of %s should use the CA Secret", name)
+
+		container := requireContainer(t, podSpec, name)
+
+		var caMount *corev1.VolumeMount
+		for i, m := range container.VolumeMounts {
+			if m.Name == "trusted-ca-bundle" {
+				caMount = &container.VolumeMounts[i]
+			}
+		}
+		require.NotNil(t, caMount, "%s should mount the CA bundle", name)
+		assert.Equal("/etc/ssl/certs/custom-ca.pem", caMount.MountPath, "CA bundle of %s should be mounted into the trust store", name)
+
+		certFile, ok := getEnv(container, "SSL_CERT_FILE")
+		require.True(t, ok, "SSL_CERT_FILE of %s should be set", name)
+		assert.Equal(caMount.MountPath, certFile.Value, "SSL_CERT_FILE of %s should point at the CA bundle", name)
+	}
+}
+
+func TestTrustedCABundleDefaultOmitted(t *testing.T) {
+	assert := assert.New(t)
+
+	namespace := generateNamespaceName()
+	chartValues := cliValues()
+
+	options := defaultOptions(namespace, chartValues)
+
+	var serverStatefulSet appsv1.StatefulSet
+
+	renderedStatefulSetTemplate, err := renderTemplate(t, options, "templates/readyset-server-statefulset.yaml")
+	require.NoError(t, err)
+
+	helm.UnmarshalK8SYaml(t, renderedStatefulSetTemplate, &serverStatefulSet)
+
+	for _, v := range serverStatefulSet.Spec.Template.Spec.Volumes {
+		assert.NotEqual("trusted-ca-bundle", v.Name, "No CA bundle volume should be rendered by default")
+	}
+
+	serverContainer := requireContainer(t, serverStatefulSet.Spec.Template.Spec, "readyset-server")
+
+	for _, m := range serverContainer.VolumeMounts {
+		assert.NotEqual("/etc/ssl/certs/custom-ca.pem", m.MountPath, "No CA bundle should be mounted by default")
+	}
+
+	_, ok := getEnv(serverContainer, "SSL_CERT_FILE")
+	assert.False(ok, "SSL_CERT_FILE should not be set by default")
+}
@@ -7,6 +7,7 @@ require (
 	github.com/stretchr/testify v1.8.4
 	helm.sh/helm/v3 v3.12.1
 	k8s.io/api v0.27.2
+	k8s.io/apimachinery v0.27.2
 )
 
 require (
@@ -68,7 +69,6 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-	k8s.io/apimachinery v0.27.2 // indirect
 	k8s.io/client-go v0.27.2 // indirect
 	k8s.io/klog/v2 v2.100.1 // indirect
 	sigs.k8s.io/json v0.0.0-20221116044647-bc3834ca7abd // indirect
@@ -0,0 +1,1706 @@
+{{/*
+Name of the chart, used for the app.kubernetes.io/name label of each component
+*/}}
+{{- define "readyset.name" -}}
+{{- default .Chart.Name .Values.nameOverride | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/*
+Namespace of all namespaced resources rendered by this chart, readyset.namespaceOverride or the release namespace
+*/}}
+{{- define "readyset.namespace" -}}
+{{- default .Release.Namespace .Values.readyset.namespaceOverride }}
+{{- end }}
+
+{{/*
+Prefix for the names of all resources rendered by this chart.
+Defaults to the chart name so that resources keep their historical names, e.g. readyset-adapter.
+*/}}
+{{- define "readyset.fullname" -}}
+{{- if .Values.fullnameOverride }}
+{{- .Values.fullnameOverride | trunc 63 | trimSuffix "-" }}
+{{- else }}
+{{- include "readyset.name" . }}
+{{- end }}
+{{- end }}
+
+{{/*
+Names of the readyset-adapter and readyset-server resources
+*/}}
+{{- define "readyset.adapter.fullname" -}}
+{{- printf "%s-adapter" (include "readyset.fullname" .) | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{- define "readyset.server.fullname" -}}
+{{- printf "%s-server" (include "readyset.fullname" .) | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/*
+Common labels applied to every resource rendered by this chart, followed by the user's commonLabels.
+commonLabels may not override app.kubernetes.io/* or helm.sh/chart, these keys are dropped.
+app.kubernetes.io/version is the version the "component" runs, see readyset.imageTag, or the chart AppVersion for
+the resources shared by both components; It is never part of a selector.
+
+Usage: include "readyset.labels" $, or include "readyset.labels" (dict "component" "server" "context" $)
+*/}}
+{{- define "readyset.labels" -}}
+{{- $context := ternary .context . (hasKey . "component") }}
+{{- $version := $context.Chart.AppVersion }}
+{{- if hasKey . "component" }}
+{{- $version = include "readyset.imageTag" (dict "component" .component "context" $context) }}
+{{- end -}}
+app.kubernetes.io/instance: {{ $context.Values.readyset.deployment }}
+app.kubernetes.io/version: {{ $version | trunc 63 | trimSuffix "-" | quote }}
+helm.sh/chart: {{ $context.Chart.Name }}-{{ $context.Chart.Version }}
+{{- range $key, $value := $context.Values.commonLabels }}
+{{- if not (or (hasPrefix "app.kubernetes.io/" $key) (eq $key "helm.sh/chart")) }}
+{{ $key }}: {{ $value | toString | quote }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+ownerReferences of the namespaced resources rendered by this chart, pointing at readyset.ownerReference. Empty unless
+readyset.ownerReference.name is set.
+*/}}
+{{- define "readyset.ownerReferences" -}}
+{{- with .Values.readyset.ownerReference }}
+{{- if .name -}}
+ownerReferences:
+  - apiVersion: {{ .apiVersion }}
+    kind: {{ .kind }}
+    name: {{ .name }}
+    uid: {{ .uid }}
+    controller: {{ .controller }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Labels of the ServiceAccounts rendered by this chart: the common labels, followed by serviceAccount.labels.
+serviceAccount.labels may not override the chart's own labels, nor commonLabels.
+
+Usage: include "readyset.serviceAccountLabels" (dict "component" "server" "context" $), as for readyset.labels
+*/}}
+{{- define "readyset.serviceAccountLabels" -}}
+{{- $context := ternary .context . (hasKey . "component") }}
+{{- $labels := include "readyset.labels" . }}
+{{- $labels }}
+{{- $existing := $labels | fromYaml }}
+{{- range $key, $value := $context.Values.serviceAccount.labels }}
+{{- if not (or (hasKey $existing $key) (hasPrefix "app.kubernetes.io/" $key)) }}
+{{ $key }}: {{ $value | toString | quote }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Labels selecting the readyset-adapter pods
+*/}}
+{{- define "readyset.adapter.selectorLabels" -}}
+app.kubernetes.io/name: {{ include "readyset.name" . }}-adapter
+app.kubernetes.io/instance: {{ .Values.readyset.deployment }}
+{{- end }}
+
+{{/*
+Pod template labels of a component: its selector labels, the common labels and the environment label of
+readyset.environment, with the component's own podLabels merged underneath. podLabels may not override the former, so
+the workload selector keeps matching its pods.
+
+Usage: include "readyset.podLabels" (dict "labels" .Values.readyset.server.podLabels "component" "server" "context" $)
+*/}}
+{{- define "readyset.podLabels" -}}
+{{- $labels := include (printf "readyset.%s.selectorLabels" .component) .context | fromYaml }}
+{{- $environment := dict }}
+{{- with .context.Values.readyset.environment }}
+{{- $environment = dict "environment" . }}
+{{- end }}
+{{- $labels = merge $labels (include "readyset.labels" (dict "component" .component "context" .context) | fromYaml) $environment (default (dict) .labels) }}
+{{- toYaml $labels }}
+{{- end }}
+
+{{/*
+Pod annotations of a component: commonAnnotations merged with the component's own
+podAnnotations, the latter taking precedence on key conflicts.
+
+Usage: include "readyset.podAnnotations" (dict "annotations" .Values.readyset.server.podAnnotations "context" $)
+*/}}
+{{- define "readyset.podAnnotations" -}}
+{{- $annotations := merge (deepCopy (default (dict) .annotations)) (default (dict) .context.Values.commonAnnotations) }}
+{{- with $annotations }}
+{{- toYaml . }}
+{{- end }}
+{{- end }}
+
+{{/*
+Pod annotations of the readyset-server: readyset.podAnnotations, along with a checksum/config of the config file
+ConfigMap when readyset.server.useConfigFile, so that config changes roll the pods
+*/}}
+{{- define "readyset.server.podAnnotations" -}}
+{{- $annotations := deepCopy (default (dict) .Values.readyset.server.podAnnotations) }}
+{{- if .Values.readyset.server.useConfigFile }}
+{{- $_ := set $annotations "checksum/config" (include (print .Template.BasePath "/readyset-server-configmap.yaml") . | sha256sum) }}
+{{- end }}
+{{- include "readyset.podAnnotations" (dict "annotations" $annotations "context" .) }}
+{{- end }}
+
+{{/*
+Service annotations of a component: the chart's own annotations, with the user's service.annotations merged on top.
+User annotations are passed through as-is and win on key conflicts. The annotations may be given as a map, or as a
+list of single-entry maps as older versions of values.yaml documented.
+
+Usage: include "readyset.serviceAnnotations" (dict "annotations" .Values.readyset.adapter.service.annotations "component" "adapter" "context" $)
+*/}}
+{{- define "readyset.serviceAnnotations" -}}
+{{- $annotations := dict "readyset.io/deployment" (toString .context.Values.readyset.deployment) "readyset.io/component" .component }}
+{{- if kindIs "slice" .annotations }}
+{{- range .annotations }}
+{{- $annotations = merge (deepCopy .) $annotations }}
+{{- end }}
+{{- else if .annotations }}
+{{- $annotations = merge (deepCopy .annotations) $annotations }}
+{{- end }}
+{{- toYaml $annotations }}
+{{- end }}
+
+{{/*
+Container port on which the readyset-adapter accepts SQL connections
+*/}}
+{{- define "readyset.adapter.sqlPort" -}}
+{{- if .Values.readyset.adapter.sqlPort }}
+{{- .Values.readyset.adapter.sqlPort }}
+{{- else if eq .Values.readyset.adapter.type "mysql" }}
+{{- 3307 }}
+{{- else }}
+{{- 5433 }}
+{{- end }}
+{{- end }}
+
+{{/*
+Name of a container port of a component, and how Services target it: readyset.<component>.portNames.<port> when set,
+else the given default name. Services keep targeting the port by number unless its name is overridden, in which case
+they target it by name so that the container and the Services stay in sync.
+
+Usage: include "readyset.portName" (dict "component" "adapter" "port" "sql" "default" "sql" "context" $)
+       include "readyset.targetPort" (dict "component" "adapter" "port" "sql" "number" 5433 "context" $)
+*/}}
+{{- define "readyset.portName" -}}
+{{- $names := default (dict) (index .context.Values.readyset .component).portNames }}
+{{- default .default (get $names .port) }}
+{{- end }}
+
+{{- define "readyset.targetPort" -}}
+{{- $names := default (dict) (index .context.Values.readyset .component).portNames }}
+{{- default .number (get $names .port) }}
+{{- end }}
+
+{{/*
+Listeners of the readyset-adapter, as a YAML list: readyset.adapter.listeners with their defaults filled in, else a
+single "sql" TCP listener on the SQL port. Parse with fromYamlArray.
+*/}}
+{{- define "readyset.adapter.listeners" -}}
+{{- $listeners := list }}
+{{- range .Values.readyset.adapter.listeners }}
+{{- if eq .type "unix" }}
+{{- $listeners = append $listeners (dict "name" .name "type" "unix" "path" (default "/var/run/readyset/readyset.sock" .path)) }}
+{{- else }}
+{{- $listeners = append $listeners (dict "name" .name "type" "tcp" "port" (default (include "readyset.adapter.sqlPort" $) .port | int)) }}
+{{- end }}
+{{- end }}
+{{- if not $listeners }}
+{{- $name := include "readyset.portName" (dict "component" "adapter" "port" "sql" "default" "sql" "context" .) }}
+{{- $listeners = list (dict "name" $name "type" "tcp" "port" (include "readyset.adapter.sqlPort" . | int)) }}
+{{- end }}
+{{- toYaml $listeners }}
+{{- end }}
+
+{{/*
+LISTEN_ADDRESS of the readyset-adapter, bound to its TCP listener if any
+*/}}
+{{- define "readyset.adapter.listenAddress" -}}
+{{- range (include "readyset.adapter.listeners" . | fromYamlArray) }}
+{{- if eq .type "tcp" }}
+{{- printf "0.0.0.0:%v" .port }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Container ports, arguments, volumes and volume mounts rendered for the readyset-adapter listeners: a port per TCP
+listener, and a --unix-socket argument plus an emptyDir holding the socket per Unix listener
+*/}}
+{{- define "readyset.adapter.listenerPorts" -}}
+{{- range (include "readyset.adapter.listeners" . | fromYamlArray) }}
+{{- if eq .type "tcp" }}
+- name: {{ .name }}
+  containerPort: {{ .port }}
+  protocol: TCP
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.adapter.listenerArgs" -}}
+{{- $args := list }}
+{{- range (include "readyset.adapter.listeners" . | fromYamlArray) }}
+{{- if eq .type "unix" }}
+{{- $args = append $args (printf "--unix-socket=%s" .path) }}
+{{- end }}
+{{- end }}
+{{- toYaml $args }}
+{{- end }}
+
+{{- define "readyset.adapter.listenerVolumes" -}}
+{{- range (include "readyset.adapter.listeners" . | fromYamlArray) }}
+{{- if eq .type "unix" }}
+- name: {{ printf "%s-socket" .name }}
+  emptyDir: {}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.adapter.listenerVolumeMounts" -}}
+{{- range (include "readyset.adapter.listeners" . | fromYamlArray) }}
+{{- if eq .type "unix" }}
+- name: {{ printf "%s-socket" .name }}
+  mountPath: {{ dir .path }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Memory-backed /dev/shm volume of the readyset-adapter, and its mount. Empty unless readyset.adapter.shm.enabled.
+*/}}
+{{- define "readyset.adapter.shmVolume" -}}
+{{- with .Values.readyset.adapter.shm }}
+{{- if .enabled -}}
+- name: shm
+  emptyDir:
+    medium: Memory
+    {{- with .sizeLimit }}
+    sizeLimit: {{ . }}
+    {{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.adapter.shmVolumeMount" -}}
+{{- if .Values.readyset.adapter.shm.enabled -}}
+- name: shm
+  mountPath: /dev/shm
+{{- end }}
+{{- end }}
+
+{{/*
+Volume holding the readyset.adapter.clientTimeouts ConfigMap, its mount, and the CLIENT_TIMEOUTS_FILE env var
+pointing the adapter at it. Empty unless readyset.adapter.clientTimeouts is set.
+*/}}
+{{- define "readyset.adapter.clientTimeoutsVolume" -}}
+{{- if .Values.readyset.adapter.clientTimeouts -}}
+- name: client-timeouts
+  configMap:
+    name: {{ include "readyset.adapter.fullname" . }}-client-timeouts
+{{- end }}
+{{- end }}
+
+{{- define "readyset.adapter.clientTimeoutsVolumeMount" -}}
+{{- if .Values.readyset.adapter.clientTimeouts -}}
+- name: client-timeouts
+  mountPath: /etc/readyset/client-timeouts
+  readOnly: true
+{{- end }}
+{{- end }}
+
+{{- define "readyset.adapter.clientTimeoutsEnv" -}}
+{{- if .Values.readyset.adapter.clientTimeouts -}}
+- name: CLIENT_TIMEOUTS_FILE
+  value: /etc/readyset/client-timeouts/client-timeouts.yaml
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-adapter and readyset-server naming the deployment environment. Empty unless
+readyset.environment is set.
+*/}}
+{{- define "readyset.environmentEnv" -}}
+{{- with .Values.readyset.environment -}}
+- name: DEPLOYMENT_ENV
+  value: {{ . | quote }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of a component, "adapter" or "server", configuring the export of its traces. Empty unless
+readyset.tracing.enabled.
+
+Usage: include "readyset.tracingEnv" (dict "component" "server" "context" $)
+*/}}
+{{- define "readyset.tracingEnv" -}}
+{{- with .context.Values.readyset.tracing }}
+{{- if .enabled -}}
+- name: TRACING_ENDPOINT
+  value: {{ .endpoint | quote }}
+- name: TRACING_SAMPLE_RATIO
+  value: {{ .samplingRatio | quote }}
+- name: TRACING_SERVICE_NAME
+  value: readyset-{{ $.component }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-adapter and readyset-server holding the shared secret they authenticate each other with.
+Empty unless readyset.clusterSecret.existingSecret is set.
+*/}}
+{{- define "readyset.clusterSecretEnv" -}}
+{{- with .Values.readyset.clusterSecret }}
+{{- if .existingSecret -}}
+- name: CLUSTER_SECRET
+  valueFrom:
+    secretKeyRef:
+      name: {{ .existingSecret }}
+      key: {{ .key }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+UPSTREAM_DB_URL of the readyset-adapter and readyset-server, from the url key of the readyset-upstream-database secret.
+With readyset.upstream.tls.enabled the secret is read into UPSTREAM_DB_BASE_URL instead, and UPSTREAM_DB_URL appends
+the sslmode and the mounted CA to it.
+*/}}
+{{- define "readyset.upstreamDbUrlEnv" -}}
+{{- with .Values.readyset.upstream.tls }}
+{{- if .enabled -}}
+- name: UPSTREAM_DB_BASE_URL
+  valueFrom:
+    secretKeyRef:
+      name: readyset-upstream-database
+      key: url
+- name: UPSTREAM_DB_URL
+  value: "$(UPSTREAM_DB_BASE_URL)?sslmode={{ .mode }}&sslrootcert=/etc/readyset/upstream-ca/ca.crt"
+{{- else -}}
+- name: UPSTREAM_DB_URL
+  valueFrom:
+    secretKeyRef:
+      name: readyset-upstream-database
+      key: url
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Volume holding the CA certificate of readyset.upstream.tls.caSecret, and its mount. Empty unless
+readyset.upstream.tls.enabled.
+*/}}
+{{- define "readyset.upstreamTLSVolumes" -}}
+{{- with .Values.readyset.upstream.tls }}
+{{- if .enabled -}}
+- name: upstream-ca
+  secret:
+    secretName: {{ .caSecret }}
+    items:
+      - key: {{ .caSecretKey }}
+        path: ca.crt
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.upstreamTLSVolumeMounts" -}}
+{{- if .Values.readyset.upstream.tls.enabled -}}
+- name: upstream-ca
+  mountPath: /etc/readyset/upstream-ca
+  readOnly: true
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-adapter and readyset-server sizing their connections to the upstream database. Options of
+readyset.upstream left unset are not rendered.
+*/}}
+{{- define "readyset.upstreamPoolEnv" -}}
+{{- with .Values.readyset.upstream }}
+{{- with .poolSize }}
+- name: UPSTREAM_DB_POOL_SIZE
+  value: {{ . | quote }}
+{{- end }}
+{{- with .connectTimeoutSeconds }}
+- name: UPSTREAM_DB_CONNECT_TIMEOUT
+  value: {{ . | quote }}
+{{- end }}
+{{- with .idleTimeoutSeconds }}
+- name: UPSTREAM_DB_IDLE_TIMEOUT
+  value: {{ . | quote }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Logging environment of the readyset-adapter and readyset-server
+*/}}
+{{- define "readyset.logEnv" -}}
+- name: LOG_LEVEL
+  value: {{ .Values.readyset.logLevel | quote }}
+- name: LOG_FORMAT
+  value: {{ include "readyset.logFormat" . | quote }}
+{{- with .Values.readyset.logging.timestampFormat }}
+- name: LOG_TIMESTAMP_FORMAT
+  value: {{ . | quote }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-adapter enabling exemplars on its metrics, served only in the OpenMetrics format
+*/}}
+{{- define "readyset.adapter.openMetricsEnv" -}}
+{{- if and .Values.readyset.metrics.enabled .Values.readyset.metrics.openMetrics.enabled -}}
+- name: METRICS_EXEMPLARS
+  value: "true"
+{{- end }}
+{{- end }}
+
+{{/*
+preStop hook draining the readyset-adapter, and the grace period covering it. Empty unless readyset.adapter.drain.enabled,
+the grace period then only when readyset.adapter.terminationGracePeriodSeconds is set.
+*/}}
+{{- define "readyset.adapter.lifecycle" -}}
+{{- with .Values.readyset.adapter.drain }}
+{{- if .enabled -}}
+preStop:
+  exec:
+    command:
+      - /bin/sh
+      - -c
+      - curl -fsS -m 5 -X POST http://127.0.0.1:{{ $.Values.readyset.adapter.httpPort }}{{ .path }}; sleep {{ .drainTimeoutSeconds }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.adapter.terminationGracePeriodSeconds" -}}
+{{- $drain := .Values.readyset.adapter.drain }}
+{{- with .Values.readyset.adapter.terminationGracePeriodSeconds }}
+{{- . }}
+{{- else }}
+{{- if $drain.enabled }}
+{{- add $drain.drainTimeoutSeconds 30 }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+affinity of the readyset-adapter pods, repelling them from nodes running readyset-server pods as set by
+readyset.adapter.antiAffinityWithServer. Empty when unset.
+*/}}
+{{- define "readyset.adapter.affinity" -}}
+{{- $term := dict "topologyKey" "kubernetes.io/hostname" "labelSelector" (dict "matchLabels" (include "readyset.server.selectorLabels" . | fromYaml)) }}
+{{- with .Values.readyset.adapter.antiAffinityWithServer -}}
+podAntiAffinity:
+  {{- if eq . "hard" }}
+  requiredDuringSchedulingIgnoredDuringExecution:
+    - {{ toYaml $term | indent 6 | trim }}
+  {{- else }}
+  preferredDuringSchedulingIgnoredDuringExecution:
+    - weight: 100
+      podAffinityTerm:
+        {{- toYaml $term | nindent 8 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Deployment strategy of the readyset-adapter; rollingUpdate is dropped for Recreate, which rejects it
+*/}}
+{{- define "readyset.adapter.strategy" -}}
+{{- with .Values.readyset.adapter.strategy -}}
+type: {{ .type }}
+{{- if and (eq .type "RollingUpdate") .rollingUpdate }}
+rollingUpdate:
+  {{- toYaml .rollingUpdate | nindent 2 }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Container arguments: the chart-managed "managed" arguments, if any, followed by the user's extra "args" in the order
+given, each of the latter rendered with tpl against the chart context. A non-empty "override" replaces both, each of
+its entries rendered with tpl as well.
+Takes a dict with "args", "context" and optionally "managed" and "override"
+*/}}
+{{- define "readyset.extraArgs" -}}
+{{- $args := default (list) .managed }}
+{{- range .args }}
+{{- $args = append $args (tpl . $.context) }}
+{{- end }}
+{{- with .override }}
+{{- $args = list }}
+{{- range . }}
+{{- $args = append $args (tpl . $.context) }}
+{{- end }}
+{{- end }}
+{{- toYaml $args }}
+{{- end }}
+
+{{/*
+Container command overriding the image entrypoint: each entry of "command" rendered with tpl against the chart
+context, empty when unset so that the image entrypoint applies.
+Takes a dict with "command" and "context"
+*/}}
+{{- define "readyset.command" -}}
+{{- $command := list }}
+{{- range .command }}
+{{- $command = append $command (tpl . $.context) }}
+{{- end }}
+{{- with $command }}
+{{- toYaml . }}
+{{- end }}
+{{- end }}
+
+{{/*
+Type of a Service: the configured "type", unless readyset.internalOnly forces ClusterIP. The LoadBalancer and NodePort
+specific settings of a Service are only rendered when this is not ClusterIP.
+
+Usage: include "readyset.serviceType" (dict "type" .Values.readyset.adapter.service.type "context" $)
+*/}}
+{{- define "readyset.serviceType" -}}
+{{- ternary "ClusterIP" .type .context.Values.readyset.internalOnly }}
+{{- end }}
+
+{{/*
+ServiceAccount the readyset-adapter pods run as, and its RBAC resources are bound to
+*/}}
+{{- define "readyset.adapter.serviceAccountName" -}}
+{{- include "readyset.adapter.fullname" . }}
+{{- end }}
+
+{{/*
+ServiceAccount name of the readyset-server pods, independent of the readyset-adapter's. readyset.server.podOrdinal.label
+implies a dedicated one, so its pod patch rights are not granted to the "default" ServiceAccount.
+*/}}
+{{- define "readyset.server.serviceAccountName" -}}
+{{- with .Values.readyset.server.serviceAccount }}
+{{- if or .create $.Values.readyset.server.podOrdinal.label }}
+{{- default (include "readyset.server.fullname" $) .name }}
+{{- else }}
+{{- default "default" .name }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+dnsPolicy, dnsConfig and hostAliases pod spec fields of a component, omitted when unset
+Takes the component values, e.g. .Values.readyset.adapter
+*/}}
+{{- define "readyset.podDns" -}}
+{{- with .dnsPolicy }}
+dnsPolicy: {{ . }}
+{{- end }}
+{{- with .dnsConfig }}
+dnsConfig:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .hostAliases }}
+hostAliases:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end }}
+
+{{/*
+envFrom container field of a component, omitted when unset. Kubernetes gives the container's env precedence over the
+keys it injects.
+Takes the component values, e.g. .Values.readyset.adapter
+*/}}
+{{- define "readyset.envFrom" -}}
+{{- with .envFrom -}}
+envFrom:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end }}
+
+{{/*
+nodeName pod spec field of the readyset-server and readyset-adapter, readyset.nodeName, omitted when unset
+*/}}
+{{- define "readyset.nodeName" -}}
+{{- with .Values.readyset.nodeName -}}
+nodeName: {{ . }}
+{{- end }}
+{{- end }}
+
+{{/*
+readinessGates pod spec field of a component, omitted when unset
+Takes the component values, e.g. .Values.readyset.adapter
+*/}}
+{{- define "readyset.readinessGates" -}}
+{{- with .readinessGates -}}
+readinessGates:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end }}
+
+{{/*
+nodeSelector pod spec field of a component, omitted when unset
+Takes the component values, e.g. .Values.readyset.adapter
+*/}}
+{{- define "readyset.nodeSelector" -}}
+{{- with .nodeSelector -}}
+nodeSelector:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end }}
+
+{{/*
+runtimeClassName pod spec field of a component, omitted when unset
+Takes the component values, e.g. .Values.readyset.adapter
+*/}}
+{{- define "readyset.runtimeClassName" -}}
+{{- with .runtimeClassName -}}
+runtimeClassName: {{ . }}
+{{- end }}
+{{- end }}
+
+{{/*
+Rules granted to the readyset-adapter, shared by its namespaced Role and its ClusterRole
+*/}}
+{{- define "readyset.adapter.rbacRules" -}}
+- apiGroups: [""]
+  resources: ["pods", "endpoints", "services"]
+  verbs: ["get", "list", "watch"]
+{{- end }}
+
+{{/*
+Tables readyset-server replicates and ignores, rendered as REPLICATION_TABLES and REPLICATION_TABLES_IGNORE:
+readyset.server.replication.include and exclude when set, with entries listed in both dropped from the former, else
+the readyset.server.replication_tables and replication_tables_ignore strings. Empty when unset.
+*/}}
+{{- define "readyset.server.replicationTables" -}}
+{{- with .Values.readyset.server }}
+{{- if .replication.include }}
+{{- $tables := list }}
+{{- range .replication.include }}
+{{- if not (has . $.Values.readyset.server.replication.exclude) }}
+{{- $tables = append $tables . }}
+{{- end }}
+{{- end }}
+{{- join "," $tables }}
+{{- else }}
+{{- .replication_tables | default "" }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.replicationTablesIgnore" -}}
+{{- with .Values.readyset.server }}
+{{- if .replication.include }}
+{{- join "," .replication.exclude }}
+{{- else }}
+{{- .replication_tables_ignore | default "" }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-server naming its replication slot and publication. Empty unless readyset.adapter.type is
+"postgresql".
+*/}}
+{{- define "readyset.server.postgresReplicationEnv" -}}
+{{- if eq .Values.readyset.adapter.type "postgresql" }}
+{{- with .Values.readyset.upstream.postgres }}
+{{- with .replicationSlot }}
+- name: REPLICATION_SLOT_NAME
+  value: {{ . | quote }}
+{{- end }}
+{{- with .publication }}
+- name: PUBLICATION_NAME
+  value: {{ . | quote }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-server pointing its initial snapshot at readyset.upstream.snapshotHost. Empty when unset, the
+snapshot then being taken from UPSTREAM_DB_URL.
+*/}}
+{{- define "readyset.server.snapshotHostEnv" -}}
+{{- with .Values.readyset.upstream }}
+{{- if .snapshotHost -}}
+- name: SNAPSHOT_HOST
+  value: {{ .snapshotHost | quote }}
+{{- with .snapshotPort }}
+- name: SNAPSHOT_PORT
+  value: {{ . | quote }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-server bounding the backoff between replication reconnects to the upstream database. Empty
+when readyset.upstream.reconnect is unset.
+*/}}
+{{- define "readyset.server.upstreamReconnectEnv" -}}
+{{- with .Values.readyset.upstream.reconnect }}
+{{- with .initialBackoffSeconds }}
+- name: UPSTREAM_RECONNECT_INITIAL_BACKOFF_SECONDS
+  value: {{ . | quote }}
+{{- end }}
+{{- with .maxBackoffSeconds }}
+- name: UPSTREAM_RECONNECT_MAX_BACKOFF_SECONDS
+  value: {{ . | quote }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Init container holding readyset-server back until the upstream database accepts connections. Empty unless
+readyset.server.waitForUpstream.enabled.
+*/}}
+{{- define "readyset.server.waitForUpstreamInitContainer" -}}
+{{- $mysql := eq .Values.readyset.adapter.type "mysql" }}
+{{- with .Values.readyset.server.waitForUpstream }}
+{{- if .enabled }}
+{{- $port := .port | default (ternary 3306 5432 $mysql) -}}
+- name: wait-for-upstream
+  image: {{ ternary .mysqlImage .postgresqlImage $mysql }}
+  env:
+    - name: UPSTREAM_DB_HOST
+      valueFrom:
+        secretKeyRef:
+          name: readyset-upstream-database
+          key: host
+  command:
+    - /bin/sh
+    - -c
+    {{- if $mysql }}
+    - timeout {{ .timeoutSeconds }} sh -c 'until mysqladmin ping -h "$UPSTREAM_DB_HOST" -P {{ $port }} --silent; do echo waiting for upstream; sleep 2; done'
+    {{- else }}
+    - timeout {{ .timeoutSeconds }} sh -c 'until pg_isready -h "$UPSTREAM_DB_HOST" -p {{ $port }}; do echo waiting for upstream; sleep 2; done'
+    {{- end }}
+  {{- with .resources }}
+  resources:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .securityContext }}
+  securityContext:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Init container running as root that hands the readyset-server data directory, and the journal when enabled, to
+readyset.server.podSecurityContext.runAsUser. Empty unless readyset.server.fixPermissions.enabled.
+*/}}
+{{- define "readyset.server.fixPermissionsInitContainer" -}}
+{{- $securityContext := .Values.readyset.server.podSecurityContext }}
+{{- $journal := .Values.readyset.server.journal }}
+{{- with .Values.readyset.server.fixPermissions }}
+{{- if .enabled }}
+{{- $owner := printf "%v:%v" $securityContext.runAsUser (default $securityContext.runAsUser $securityContext.runAsGroup) -}}
+- name: fix-permissions
+  image: {{ .image }}
+  command:
+    - chown
+    - -R
+    - {{ $owner | quote }}
+    - {{ .path }}
+    {{- if $journal.enabled }}
+    - {{ $journal.path }}
+    {{- end }}
+  securityContext:
+    runAsUser: 0
+    runAsNonRoot: false
+  volumeMounts:
+    - name: state
+      mountPath: {{ .path }}
+    {{- include "readyset.server.journalVolumeMount" $ | nindent 4 }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+restartPolicy of the pods of a chart-managed Job: readyset.hooks.restartPolicy when set, else the Job's own "default"
+
+Usage: include "readyset.hooks.restartPolicy" (dict "default" "OnFailure" "context" $)
+*/}}
+{{- define "readyset.hooks.restartPolicy" -}}
+restartPolicy: {{ .context.Values.readyset.hooks.restartPolicy | default .default }}
+{{- end }}
+
+{{/*
+backoffLimit of a chart-managed Job: its own "backoffLimit" override when set, else readyset.hooks.backoffLimit, else
+the Job's own "default". Empty when all are unset, leaving the Kubernetes default.
+
+Usage: include "readyset.hooks.backoffLimit" (dict "backoffLimit" .backoffLimit "default" 0 "context" $)
+*/}}
+{{- define "readyset.hooks.backoffLimit" -}}
+{{- $backoffLimit := .default }}
+{{- if not (kindIs "invalid" .context.Values.readyset.hooks.backoffLimit) }}
+{{- $backoffLimit = .context.Values.readyset.hooks.backoffLimit }}
+{{- end }}
+{{- if not (kindIs "invalid" .backoffLimit) }}
+{{- $backoffLimit = .backoffLimit }}
+{{- end }}
+{{- if not (kindIs "invalid" $backoffLimit) -}}
+backoffLimit: {{ $backoffLimit }}
+{{- end }}
+{{- end }}
+
+{{/*
+Container running a file of CREATE CACHE statements against the readyset-adapter Service, with psql or mysql depending
+on readyset.adapter.type and the credentials of the readyset-upstream-database secret, from readyset.hooks.cachesImage.
+Expects a "caches" volume holding the file.
+With "retries", first waits for the adapter to accept connections, checking up to that many times "interval" seconds
+apart.
+
+Usage: include "readyset.createCachesContainer" (dict "file" "/caches/caches.sql" "context" $)
+*/}}
+{{- define "readyset.createCachesContainer" -}}
+{{- $mysql := eq .context.Values.readyset.adapter.type "mysql" }}
+{{- $host := include "readyset.adapter.fullname" .context }}
+{{- $port := .context.Values.readyset.adapter.service.port }}
+{{- $wait := "" }}
+{{- if .retries }}
+{{- $check := ternary (printf "mysqladmin ping -h %s -P %v --silent" $host $port) (printf "pg_isready -h %s -p %v" $host $port) $mysql }}
+{{- $wait = printf "n=0; until %s; do n=$((n+1)); if [ \"$n\" -ge %d ]; then echo adapter not reachable after %d attempts; exit 1; fi; echo waiting for the adapter; sleep %d; done; " $check (int .retries) (int .retries) (int .interval) }}
+{{- end }}
+{{- $waitForUpstream := .context.Values.readyset.server.waitForUpstream -}}
+- name: create-caches
+  image: {{ .context.Values.readyset.hooks.cachesImage | default (ternary $waitForUpstream.mysqlImage $waitForUpstream.postgresqlImage $mysql) }}
+  env:
+    {{- range $name, $key := dict "DB_USER" "username" "DB_PASSWORD" "password" "DB_NAME" "database" }}
+    - name: {{ $name }}
+      valueFrom:
+        secretKeyRef:
+          name: readyset-upstream-database
+          key: {{ $key }}
+    {{- end }}
+  command:
+    - /bin/sh
+    - -c
+    {{- if $mysql }}
+    - {{ $wait }}mysql -h {{ $host }} -P {{ $port }} -u "$DB_USER" -p"$DB_PASSWORD" "$DB_NAME" < {{ .file }}
+    {{- else }}
+    - {{ $wait }}PGPASSWORD="$DB_PASSWORD" psql -h {{ $host }} -p {{ $port }} -U "$DB_USER" -d "$DB_NAME" -v ON_ERROR_STOP=1 -f {{ .file }}
+    {{- end }}
+  volumeMounts:
+    - name: caches
+      mountPath: /caches
+{{- end }}
+
+{{/*
+httpGet handler of the HTTP probes of a component, "adapter" or "server", against its HTTP port, with the scheme and
+path of its probes values
+
+Usage: include "readyset.probeHttpGet" (dict "component" "server" "context" $)
+*/}}
+{{- define "readyset.probeHttpGet" -}}
+{{- $values := get .context.Values.readyset .component }}
+{{- $port := ternary $values.httpPort $values.service.httpPort (eq .component "adapter") -}}
+httpGet:
+  scheme: {{ $values.probes.scheme }}
+  path: {{ $values.probes.path }}
+  port: {{ $port }}
+{{- end }}
+
+{{/*
+Startup probe of the readyset-server, holding its liveness probe back until the server is up.
+readyset.server.snapshot.disableLivenessDuring swaps in the larger failure budget needed by long initial snapshots.
+Empty when the startup probe is disabled.
+*/}}
+{{- define "readyset.server.startupProbe" -}}
+{{- $probe := dict }}
+{{- if .Values.readyset.server.snapshot.disableLivenessDuring }}
+{{- $probe = .Values.readyset.server.snapshot.startupProbe }}
+{{- else if .Values.readyset.server.probes.startup.enabled }}
+{{- $probe = .Values.readyset.server.probes.startup }}
+{{- end }}
+{{- with $probe -}}
+{{ include "readyset.probeHttpGet" (dict "component" "server" "context" $) }}
+periodSeconds: {{ .periodSeconds }}
+failureThreshold: {{ .failureThreshold }}
+{{- end }}
+{{- end }}
+
+{{/*
+Number of bytes of a Kubernetes quantity, e.g. 4Gi
+*/}}
+{{- define "readyset.quantityToBytes" -}}
+{{- $quantity := toString . }}
+{{- $number := regexFind "^[0-9]+(\\.[0-9]+)?" $quantity }}
+{{- $suffix := trimPrefix $number $quantity }}
+{{- $multipliers := dict "" 1 "k" 1000 "M" 1000000 "G" 1000000000 "T" 1000000000000 "Ki" 1024 "Mi" 1048576 "Gi" 1073741824 "Ti" 1099511627776 }}
+{{- if or (not $number) (not (hasKey $multipliers $suffix)) }}
+{{- fail (printf "%s is not a supported memory quantity" $quantity) }}
+{{- end }}
+{{- mulf $number (get $multipliers $suffix) | floor | int64 }}
+{{- end }}
+
+{{/*
+Memory target of the readyset-server eviction logic, in bytes: readyset.server.memoryLimitBytes, else derived as
+memoryLimitDerivePercentage of the memory limit. Empty when neither applies.
+*/}}
+{{- define "readyset.server.memoryLimit" -}}
+{{- with .Values.readyset.server }}
+{{- if .memoryLimitBytes }}
+{{- .memoryLimitBytes | int64 }}
+{{- else if and .memoryLimitDerivePercentage (dig "limits" "memory" "" .resources) }}
+{{- $bytes := include "readyset.quantityToBytes" .resources.limits.memory }}
+{{- divf (mulf $bytes .memoryLimitDerivePercentage) 100 | floor | int64 }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Port of readyset.server.prometheus.address
+*/}}
+{{- define "readyset.server.prometheusPort" -}}
+{{- last (splitList ":" (toString .Values.readyset.server.prometheus.address)) }}
+{{- end }}
+
+{{/*
+Name of the headless Service governing the readyset-server StatefulSet, used as its serviceName
+*/}}
+{{- define "readyset.server.headlessServiceName" -}}
+{{- default (printf "%s-headless" (include "readyset.server.fullname" .)) .Values.readyset.server.headlessService.name | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/*
+Name of the headless Service governing the readyset-server reader StatefulSet, used as its serviceName
+*/}}
+{{- define "readyset.server.readerHeadlessServiceName" -}}
+{{- printf "%s-reader-headless" (include "readyset.server.fullname" .) | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/*
+Subdomain of the readyset-server pods, the governing headless Service unless overridden
+*/}}
+{{- define "readyset.server.subdomain" -}}
+{{- default (include "readyset.server.headlessServiceName" .) .Values.readyset.server.subdomain }}
+{{- end }}
+
+{{/*
+Metadata of a readyset-server volumeClaimTemplate: readyset.server.persistence.labels and annotations on top of
+the selector labels, which the PVC annotator Job relies on and therefore cannot be overridden.
+
+Usage: {{ include "readyset.server.volumeClaimMetadata" (dict "name" "state" "context" $) }}
+*/}}
+{{- define "readyset.server.volumeClaimMetadata" -}}
+{{- $persistence := .context.Values.readyset.server.persistence -}}
+{{- $labels := merge (include "readyset.server.selectorLabels" .context | fromYaml) (default dict $persistence.labels) -}}
+name: {{ .name }}
+labels:
+  {{- toYaml $labels | nindent 2 }}
+{{- with $persistence.annotations }}
+annotations:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end }}
+
+{{/*
+Data volume of the readyset-server, and its mount: a volumeClaimTemplate, or the volume of
+readyset.server.persistence.existingClaim, which replaces it. Each of the former two is empty in the other case.
+*/}}
+{{- define "readyset.server.stateVolumeClaimTemplate" -}}
+{{- if not .Values.readyset.server.persistence.existingClaim -}}
+- metadata:
+    {{- include "readyset.server.volumeClaimMetadata" (dict "name" "state" "context" $) | nindent 4 }}
+  spec:
+    accessModes:
+      - ReadWriteOnce
+    {{- with .Values.kubernetes.storageClass }}
+    storageClassName: {{ . }}
+    {{- end }}
+    resources:
+      requests:
+        storage: {{ .Values.readyset.server.resources.requests.storage }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.stateVolume" -}}
+{{- with .Values.readyset.server.persistence.existingClaim -}}
+- name: state
+  persistentVolumeClaim:
+    claimName: {{ . }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.stateVolumeMount" -}}
+- name: state
+  mountPath: {{ .Values.readyset.server.fixPermissions.path }}
+  {{- with .Values.readyset.server.persistence.subPath }}
+  subPath: {{ . }}
+  {{- end }}
+{{- end }}
+
+{{/*
+volumeClaimTemplate of the readyset-server journal, and its mount. Empty unless readyset.server.journal.enabled.
+*/}}
+{{- define "readyset.server.journalVolumeClaimTemplate" -}}
+{{- with .Values.readyset.server.journal }}
+{{- if .enabled -}}
+- metadata:
+    {{- include "readyset.server.volumeClaimMetadata" (dict "name" "journal" "context" $) | nindent 4 }}
+  spec:
+    accessModes:
+      - ReadWriteOnce
+    {{- with (default $.Values.kubernetes.storageClass .storageClassName) }}
+    storageClassName: {{ . }}
+    {{- end }}
+    resources:
+      requests:
+        storage: {{ .size }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.journalVolumeMount" -}}
+{{- with .Values.readyset.server.journal }}
+{{- if .enabled -}}
+- name: journal
+  mountPath: {{ .path }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Volume holding the readyset-server config file ConfigMap, its mount, and the CONFIG_FILE env var pointing
+readyset-server at it. Empty unless readyset.server.useConfigFile.
+*/}}
+{{- define "readyset.server.configVolume" -}}
+{{- if .Values.readyset.server.useConfigFile -}}
+- name: config
+  configMap:
+    name: {{ include "readyset.server.fullname" . }}-config
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.configVolumeMount" -}}
+{{- if .Values.readyset.server.useConfigFile -}}
+- name: config
+  mountPath: /etc/readyset/config
+  readOnly: true
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.configEnv" -}}
+{{- if .Values.readyset.server.useConfigFile -}}
+- name: CONFIG_FILE
+  value: /etc/readyset/config/config.yaml
+{{- end }}
+{{- end }}
+
+{{/*
+Volume shared with sidecars holding the readyset-server status file, its mount, and the STATUS_FILE env var pointing
+readyset-server at the file. Empty unless readyset.server.statusFile.enabled.
+*/}}
+{{- define "readyset.server.statusFileVolume" -}}
+{{- if .Values.readyset.server.statusFile.enabled -}}
+- name: status
+  emptyDir: {}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.statusFileVolumeMount" -}}
+{{- with .Values.readyset.server.statusFile }}
+{{- if .enabled -}}
+- name: status
+  mountPath: {{ dir .path }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.statusFileEnv" -}}
+{{- with .Values.readyset.server.statusFile }}
+{{- if .enabled -}}
+- name: STATUS_FILE
+  value: {{ .path }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-server naming its pod through the downward API. POD_ORDINAL is projected from
+metadata.name too, the trailing number of which is the StatefulSet ordinal.
+*/}}
+{{- define "readyset.server.podOrdinalEnv" -}}
+- name: POD_NAME
+  valueFrom:
+    fieldRef:
+      fieldPath: metadata.name
+- name: POD_ORDINAL
+  valueFrom:
+    fieldRef:
+      fieldPath: metadata.name
+{{- end }}
+
+{{/*
+Init container labelling its readyset-server pod with its StatefulSet ordinal under readyset.io/pod-ordinal. Empty
+unless readyset.server.podOrdinal.label.
+*/}}
+{{- define "readyset.server.podOrdinalLabelInitContainer" -}}
+{{- with .Values.readyset.server.podOrdinal }}
+{{- if .label -}}
+- name: label-pod-ordinal
+  image: {{ .image }}
+  env:
+    - name: POD_NAME
+      valueFrom:
+        fieldRef:
+          fieldPath: metadata.name
+  command:
+    - /bin/sh
+    - -c
+    - kubectl label pod "$POD_NAME" --namespace={{ include "readyset.namespace" $ }} --overwrite readyset.io/pod-ordinal="${POD_NAME##*-}"
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Scratch space volume of the readyset-server, and its mount
+*/}}
+{{- define "readyset.server.tmpVolume" -}}
+{{- with .Values.readyset.server.tmpVolume -}}
+- name: tmp
+  {{- if or .medium .sizeLimit }}
+  emptyDir:
+    {{- with .medium }}
+    medium: {{ . }}
+    {{- end }}
+    {{- with .sizeLimit }}
+    sizeLimit: {{ . }}
+    {{- end }}
+  {{- else }}
+  emptyDir: {}
+  {{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.tmpVolumeMount" -}}
+- name: tmp
+  mountPath: {{ .Values.readyset.server.tmpVolume.mountPath }}
+{{- end }}
+
+{{/*
+topologySpreadConstraints of the readyset-server pods: readyset.server.topologySpreadConstraints when set, else a
+zone spread generated by readyset.server.autoTopologySpread for more than one replica, else empty
+*/}}
+{{- define "readyset.server.topologySpreadConstraints" -}}
+{{- with .Values.readyset.server }}
+{{- if .topologySpreadConstraints }}
+{{- toYaml .topologySpreadConstraints }}
+{{- else if and .autoTopologySpread.enabled (gt (int .replicaCount) 1) -}}
+- maxSkew: 1
+  topologyKey: topology.kubernetes.io/zone
+  whenUnsatisfiable: {{ .autoTopologySpread.whenUnsatisfiable }}
+  labelSelector:
+    matchLabels:
+      {{- include "readyset.server.selectorLabels" $ | nindent 6 }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Labels selecting the readyset-server pods, and the PVCs its StatefulSet provisions
+*/}}
+{{- define "readyset.server.selectorLabels" -}}
+app.kubernetes.io/name: {{ include "readyset.name" . }}-server
+app.kubernetes.io/instance: {{ .Values.readyset.deployment }}
+{{- end }}
+
+{{/*
+Log format of the readyset-server and readyset-adapter: readyset.logging.format, else readyset.logFormat
+*/}}
+{{- define "readyset.logFormat" -}}
+{{- default .Values.readyset.logFormat .Values.readyset.logging.format }}
+{{- end }}
+
+{{/*
+Image reference of a component, "adapter" or "server".
+The digest takes precedence over the tag, and the global image.registry is prepended when set.
+The legacy imageRepository and imageTag values are used as fallbacks.
+
+Usage: include "readyset.image" (dict "component" "server" "context" $)
+*/}}
+{{- define "readyset.image" -}}
+{{- $values := get .context.Values.readyset .component }}
+{{- $image := default (dict) $values.image }}
+{{- $repository := $image.repository | default (printf "%s/readyset-%s" (default "public.ecr.aws/readyset" $values.imageRepository) .component) }}
+{{- with .context.Values.image.registry }}
+{{- $repository = printf "%s/%s" (trimSuffix "/" .) $repository }}
+{{- end }}
+{{- if $image.digest }}
+{{- printf "%s@%s" $repository $image.digest }}
+{{- else }}
+{{- printf "%s:%s" $repository (include "readyset.imageTag" .) }}
+{{- end }}
+{{- end }}
+
+{{/*
+Version a component runs: its image tag, falling back to the legacy imageTag and then to the chart AppVersion. The
+AppVersion is also used when the image is pinned by digest, which does not name a version.
+
+Usage: include "readyset.imageTag" (dict "component" "server" "context" $)
+*/}}
+{{- define "readyset.imageTag" -}}
+{{- $values := get .context.Values.readyset .component }}
+{{- $image := default (dict) $values.image }}
+{{- if $image.digest }}
+{{- .context.Chart.AppVersion }}
+{{- else }}
+{{- $image.tag | default $values.imageTag | default .context.Chart.AppVersion }}
+{{- end }}
+{{- end }}
+
+{{/*
+Images of the readyset-adapter and readyset-server
+*/}}
+{{- define "readyset.adapter.image" -}}
+{{- include "readyset.image" (dict "component" "adapter" "context" .) }}
+{{- end }}
+
+{{- define "readyset.server.image" -}}
+{{- include "readyset.image" (dict "component" "server" "context" .) }}
+{{- end }}
+
+{{/*
+Volumes holding the Consul mutual TLS Secrets, their mounts, and the env vars pointing the Consul client of
+readyset-server and readyset-adapter at them. Empty unless readyset.authority.consul.tls.enabled.
+*/}}
+{{- define "readyset.consulTLSVolumes" -}}
+{{- with .Values.readyset.authority.consul.tls }}
+{{- if .enabled -}}
+- name: consul-ca
+  secret:
+    secretName: {{ .caSecret }}
+- name: consul-cert
+  secret:
+    secretName: {{ .certSecret }}
+- name: consul-key
+  secret:
+    secretName: {{ .keySecret }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.consulTLSVolumeMounts" -}}
+{{- if .Values.readyset.authority.consul.tls.enabled -}}
+- name: consul-ca
+  mountPath: /etc/readyset/consul/ca
+  readOnly: true
+- name: consul-cert
+  mountPath: /etc/readyset/consul/cert
+  readOnly: true
+- name: consul-key
+  mountPath: /etc/readyset/consul/key
+  readOnly: true
+{{- end }}
+{{- end }}
+
+{{- define "readyset.consulTLSEnv" -}}
+{{- if .Values.readyset.authority.consul.tls.enabled -}}
+- name: CONSUL_HTTP_SSL
+  value: "true"
+- name: CONSUL_CACERT
+  value: /etc/readyset/consul/ca/ca.crt
+- name: CONSUL_CLIENT_CERT
+  value: /etc/readyset/consul/cert/tls.crt
+- name: CONSUL_CLIENT_KEY
+  value: /etc/readyset/consul/key/tls.key
+{{- end }}
+{{- end }}
+
+{{/*
+Volume holding the trusted CA bundle of readyset.trustedCABundle.secret, its mount into the OS trust store, and the
+SSL_CERT_FILE env var pointing the TLS clients of readyset-server and readyset-adapter at it. Empty unless the secret is
+set.
+*/}}
+{{- define "readyset.trustedCABundleVolumes" -}}
+{{- with .Values.readyset.trustedCABundle }}
+{{- if .secret -}}
+- name: trusted-ca-bundle
+  secret:
+    secretName: {{ .secret }}
+    items:
+      - key: {{ .secretKey }}
+        path: custom-ca.pem
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.trustedCABundleVolumeMounts" -}}
+{{- if .Values.readyset.trustedCABundle.secret -}}
+- name: trusted-ca-bundle
+  mountPath: /etc/ssl/certs/custom-ca.pem
+  subPath: custom-ca.pem
+  readOnly: true
+{{- end }}
+{{- end }}
+
+{{- define "readyset.trustedCABundleEnv" -}}
+{{- if .Values.readyset.trustedCABundle.secret -}}
+- name: SSL_CERT_FILE
+  value: /etc/ssl/certs/custom-ca.pem
+{{- end }}
+{{- end }}
+
+{{/*
+Address of the authority: an external Consul cluster, readyset.authority_address, or the bundled Consul cluster.
+The standalone authority only uses readyset.authority_address, when set.
+*/}}
+{{- define "readyset.authorityAddress" -}}
+{{- with .Values.readyset.authority.external }}
+{{- if eq $.Values.readyset.authority.type "standalone" }}
+{{- $.Values.readyset.authority_address }}
+{{- else if .address }}
+{{- if .scheme }}
+{{- printf "%s://%s" .scheme .address }}
+{{- else }}
+{{- .address }}
+{{- end }}
+{{- else if $.Values.readyset.authority_address }}
+{{- $.Values.readyset.authority_address }}
+{{- else }}
+{{- printf "%s-consul-server:8500" $.Release.Name }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+consul-agent sidecar of the readyset-server and readyset-adapter pods, a Consul client agent joining the servers of
+the authority address, and the volume holding its data. Empty with the standalone authority, which has no Consul
+cluster to join.
+
+Usage: include "readyset.consulAgentContainer" (dict "resources" .Values.readyset.server.sidecar.resources "context" $)
+*/}}
+{{- define "readyset.consulAgentContainer" -}}
+{{- if eq .context.Values.readyset.authority.type "consul" }}
+{{- $address := include "readyset.authorityAddress" .context | splitList "://" | last -}}
+- name: consul-agent
+  image: {{ .context.Values.readyset.authority.consul.agentImage }}
+  args:
+    - agent
+    - -advertise=$(POD_IP)
+    - -bind=0.0.0.0
+    - -client=127.0.0.1
+    - -data-dir=/consul/data
+    - -retry-join={{ first (splitList ":" $address) }}
+  env:
+    - name: POD_IP
+      valueFrom:
+        fieldRef:
+          fieldPath: status.podIP
+  {{- with .resources }}
+  resources:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  volumeMounts:
+    - name: consul-data
+      mountPath: /consul/data
+{{- end }}
+{{- end }}
+
+{{- define "readyset.consulAgentVolume" -}}
+{{- if eq .Values.readyset.authority.type "consul" -}}
+- name: consul-data
+  emptyDir: {}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment of the readyset-adapter and readyset-server containers, in the order they are rendered. Each is passed
+through readyset.orderedEnv, so a later entry of the same name overrides an earlier one without moving it.
+*/}}
+{{- define "readyset.adapter.env" -}}
+{{- with .Values.readyset.adapter }}
+{{ include "readyset.upstreamDbUrlEnv" $ }}
+- name: LISTEN_ADDRESS
+  value: {{ include "readyset.adapter.listenAddress" $ | quote }}
+- name: AUTHORITY_ADDRESS
+  value: {{ include "readyset.authorityAddress" $ | quote }}
+- name: DEPLOYMENT
+  value: {{ $.Values.readyset.deployment | quote }}
+- name: QUERY_CACHING
+  value: {{ $.Values.readyset.queryCachingMode | quote }}
+- name: AUTHORITY
+  value: {{ $.Values.readyset.authority.type | quote }}
+- name: DATABASE_TYPE
+  value: {{ .type | quote }}
+- name: QUERY_LOG_AD_HOC
+  value: {{ .queryLogAdHoc | quote }}
+- name: STATEMENT_LOGGING
+  value: {{ .statementLogging | quote }}
+- name: METRICS_ADDRESS
+  value: "0.0.0.0:{{ .httpPort }}"
+- name: ON_SERVER_UNAVAILABLE
+  value: {{ .onServerUnavailable | quote }}
+- name: CACHE_QUERY_TYPES
+  value: {{ join "," .cacheQueryTypes | quote }}
+{{ include "readyset.logEnv" $ }}
+{{- if $.Values.readyset.authority.external.token.secretName }}
+- name: CONSUL_HTTP_TOKEN
+  valueFrom:
+    secretKeyRef:
+      name: {{ $.Values.readyset.authority.external.token.secretName }}
+      key: {{ $.Values.readyset.authority.external.token.secretKey }}
+{{- end }}
+{{- if .readWriteSplit.enabled }}
+- name: PASSTHROUGH_WRITES
+  value: "true"
+{{- end }}
+{{- if .pprof.enabled }}
+- name: PPROF_ADDRESS
+  value: "0.0.0.0:{{ .pprof.port }}"
+{{- end }}
+{{- if not (kindIs "invalid" .fallback.enabled) }}
+- name: FALLBACK_ON_TIMEOUT
+  value: {{ .fallback.enabled | quote }}
+{{- end }}
+{{- with .fallback.timeoutMs }}
+- name: FALLBACK_TIMEOUT_MS
+  value: {{ . | quote }}
+{{- end }}
+{{- with .workerThreads }}
+- name: WORKER_THREADS
+  value: {{ . | quote }}
+{{- end }}
+{{- with .maxConnections }}
+- name: MAX_CONNECTIONS
+  value: {{ . | quote }}
+{{- end }}
+{{- with .clientIdleTimeoutSeconds }}
+- name: CLIENT_IDLE_TIMEOUT
+  value: {{ . | quote }}
+{{- end }}
+{{- if not (kindIs "invalid" .memoryShedThresholdPercent) }}
+- name: MEMORY_SHED_THRESHOLD_PERCENT
+  value: {{ .memoryShedThresholdPercent | quote }}
+{{- end }}
+{{- with .auth.mode }}
+- name: AUTH_METHOD
+  value: {{ . | quote }}
+{{- end }}
+{{- with .protocolVersion }}
+- name: PROTOCOL_VERSION
+  value: {{ . | quote }}
+{{- end }}
+{{- if .readYourWrites.enabled }}
+- name: READ_YOUR_WRITES
+  value: "true"
+- name: READ_YOUR_WRITES_WINDOW_MS
+  value: {{ .readYourWrites.windowMs | quote }}
+{{- end }}
+{{- with .defaultSchema }}
+- name: DEFAULT_SCHEMA
+  value: {{ . | quote }}
+{{- end }}
+{{- with .metrics.latencyBuckets }}
+- name: METRICS_LATENCY_BUCKETS
+  value: {{ join "," . | quote }}
+{{- end }}
+{{- if .drain.enabled }}
+- name: DRAIN_TIMEOUT_SECONDS
+  value: {{ .drain.drainTimeoutSeconds | quote }}
+{{- end }}
+{{- with (include "readyset.upstreamPoolEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.clusterSecretEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.environmentEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.adapter.openMetricsEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.adapter.clientTimeoutsEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.consulTLSEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.tracingEnv" (dict "component" "adapter" "context" $)) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.trustedCABundleEnv" $) }}
+{{ . }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "readyset.server.env" -}}
+{{- with .Values.readyset.server }}
+{{ include "readyset.upstreamDbUrlEnv" $ }}
+- name: AUTHORITY
+  value: {{ $.Values.readyset.authority.type | quote }}
+- name: AUTHORITY_ADDRESS
+  value: {{ include "readyset.authorityAddress" $ | quote }}
+- name: DEPLOYMENT
+  value: {{ $.Values.readyset.deployment | quote }}
+- name: LISTEN_ADDRESS
+  value: "0.0.0.0:{{ .service.httpPort }}"
+- name: EXTERNAL_ADDRESS
+  valueFrom:
+    fieldRef:
+      fieldPath: status.podIP
+- name: DB_DIR
+  value: {{ .fixPermissions.path | quote }}
+- name: QUORUM
+  value: {{ .replicaCount | quote }}
+- name: SHARDS
+  value: "0"
+- name: DATABASE_TYPE
+  value: {{ $.Values.readyset.adapter.type | quote }}
+- name: STATEMENT_LOGGING
+  value: {{ .statementLogging | quote }}
+{{ include "readyset.server.podOrdinalEnv" $ }}
+{{ include "readyset.logEnv" $ }}
+{{- with (include "readyset.server.replicationTables" $) }}
+- name: REPLICATION_TABLES
+  value: {{ . | quote }}
+{{- end }}
+{{- with (include "readyset.server.replicationTablesIgnore" $) }}
+- name: REPLICATION_TABLES_IGNORE
+  value: {{ . | quote }}
+{{- end }}
+{{- if $.Values.readyset.authority.external.token.secretName }}
+- name: CONSUL_HTTP_TOKEN
+  valueFrom:
+    secretKeyRef:
+      name: {{ $.Values.readyset.authority.external.token.secretName }}
+      key: {{ $.Values.readyset.authority.external.token.secretKey }}
+{{- end }}
+{{- with .readers }}
+- name: READER_REPLICAS
+  value: {{ . | quote }}
+{{- end }}
+{{- with .replication.maxBufferBytes }}
+- name: REPLICATION_MAX_BUFFER_SIZE
+  value: {{ . | quote }}
+{{- end }}
+{{- with .replication.heartbeatIntervalSeconds }}
+- name: REPLICATION_HEARTBEAT_INTERVAL
+  value: {{ . | quote }}
+{{- end }}
+{{- with .replication.onSchemaChange }}
+- name: ON_SCHEMA_CHANGE
+  value: {{ . | quote }}
+{{- end }}
+{{- with (include "readyset.server.memoryLimit" $) }}
+- name: MEMORY_LIMIT
+  value: {{ . | quote }}
+{{- end }}
+{{- with .maxCachedQueries }}
+- name: MAX_CACHED_QUERIES
+  value: {{ . | quote }}
+{{- end }}
+{{- with .prometheus }}
+{{- if .enabled }}
+- name: PROMETHEUS_METRICS
+  value: "true"
+- name: PROMETHEUS_ADDRESS
+  value: {{ .address | quote }}
+{{- end }}
+{{- end }}
+{{- with .debug }}
+{{- if .endpointEnabled }}
+- name: ENABLE_DEBUG_ENDPOINT
+  value: "true"
+- name: DEBUG_ENDPOINT_PORT
+  value: {{ .port | quote }}
+{{- end }}
+{{- end }}
+{{- with .journal }}
+{{- if .enabled }}
+- name: JOURNAL_DIR
+  value: {{ .path | quote }}
+{{- end }}
+{{- end }}
+{{- with (include "readyset.upstreamPoolEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.server.snapshotHostEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.server.postgresReplicationEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.server.upstreamReconnectEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.clusterSecretEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.environmentEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.server.configEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.server.statusFileEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.consulTLSEnv" $) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.tracingEnv" (dict "component" "server" "context" $)) }}
+{{ . }}
+{{- end }}
+{{- with (include "readyset.trustedCABundleEnv" $) }}
+{{ . }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Renders a list of env vars keyed by name: each name keeps the position of its first occurrence and the value of its
+last one.
+
+Usage: include "readyset.orderedEnv" (include "readyset.adapter.env" $)
+*/}}
+{{- define "readyset.orderedEnv" -}}
+{{- $names := list }}
+{{- $byName := dict }}
+{{- range (fromYamlArray .) }}
+{{- if not (hasKey $byName .name) }}
+{{- $names = append $names .name }}
+{{- end }}
+{{- $_ := set $byName .name . }}
+{{- end }}
+{{- $env := list }}
+{{- range $names }}
+{{- $env = append $env (get $byName .) }}
+{{- end }}
+{{- toYaml $env }}
+{{- end }}
+
+{{/*
+Resources of the readyset-adapter and readyset-server containers: the memory request doubles as the memory limit, or
+the other way around when limits.memory is set, to avoid OOM kills; CPU is requested but not limited, to avoid
+throttling. The cluster-level storage values are not container resources and are skipped.
+Takes the component resources, e.g. .Values.readyset.adapter.resources
+*/}}
+{{- define "readyset.resources" -}}
+{{- $memory := dig "limits" "memory" "" . | default (dig "requests" "memory" "" .) }}
+{{- $cpu := dig "requests" "cpu" "" . }}
+{{- if or $memory $cpu -}}
+requests:
+  {{- with $cpu }}
+  cpu: {{ . | quote }}
+  {{- end }}
+  {{- with $memory }}
+  memory: {{ . | quote }}
+  {{- end }}
+{{- with $memory }}
+limits:
+  memory: {{ . | quote }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+PriorityClass referenced by the readyset-server and readyset-adapter pods, when the chart creates one
+*/}}
+{{- define "readyset.priorityClassName" -}}
+{{- default (include "readyset.fullname" .) .Values.readyset.priorityClass.name }}
+{{- end }}